@@ -14,7 +14,6 @@ import (
 	"os"
 	"os/exec"
 	"path"
-	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -30,6 +29,7 @@ import (
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/fileutils"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/filters"
@@ -83,6 +83,14 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	rm := cmd.Bool([]string{"#rm", "-rm"}, true, "Remove intermediate containers after a successful build")
 	forceRm := cmd.Bool([]string{"-force-rm"}, false, "Always remove intermediate containers, even after unsuccessful builds")
 	pull := cmd.Bool([]string{"-pull"}, false, "Always attempt to pull a newer version of the image")
+	dockerfileName := cmd.String([]string{"f", "-file"}, "", "Name of the Dockerfile (Default is 'PATH/Dockerfile'), - to read it from STDIN")
+	flBuildArgs := opts.NewListOpts(opts.ValidateEnv)
+	cmd.Var(&flBuildArgs, []string{"-build-arg"}, "Set build-time variables declared with ARG (e.g. name=value)")
+	target := cmd.String([]string{"-target"}, "", "Name of the stage to build in a multi-stage Dockerfile")
+	squash := cmd.Bool([]string{"-squash"}, false, "Squash the layers created by the build into a single layer")
+	check := cmd.Bool([]string{"-check"}, false, "Check the Dockerfile for issues without building it")
+	flCacheFrom := opts.NewListOpts(nil)
+	cmd.Var(&flCacheFrom, []string{"-cache-from"}, "Images to use as the build cache, with their parents")
 	if err := cmd.Parse(args); err != nil {
 		return nil
 	}
@@ -95,8 +103,20 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		context  archive.Archive
 		isRemote bool
 		err      error
+		// the Dockerfile when it is sent separately from the context
+		dockerfile []byte
 	)
 
+	if *dockerfileName == "-" {
+		if cmd.Arg(0) == "-" {
+			return fmt.Errorf("The context and the Dockerfile can't both be read from STDIN")
+		}
+		if dockerfile, err = ioutil.ReadAll(cli.in); err != nil {
+			return fmt.Errorf("failed to read Dockerfile from STDIN: %v", err)
+		}
+		*dockerfileName = ""
+	}
+
 	_, err = exec.LookPath("git")
 	hasGit := err == nil
 	if cmd.Arg(0) == "-" {
@@ -117,10 +137,14 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 			context = ioutil.NopCloser(buf)
 		}
 	} else if urlutil.IsURL(cmd.Arg(0)) && (!urlutil.IsGitURL(cmd.Arg(0)) || !hasGit) {
+		if dockerfile != nil {
+			return fmt.Errorf("A Dockerfile from STDIN can't be used with a remote context")
+		}
 		isRemote = true
 	} else {
 		root := cmd.Arg(0)
-		if urlutil.IsGitURL(root) {
+		isGit := urlutil.IsGitURL(root)
+		if isGit {
 			remoteURL := cmd.Arg(0)
 			if !urlutil.IsGitTransport(remoteURL) {
 				remoteURL = "https://" + remoteURL
@@ -139,29 +163,48 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		if _, err := os.Stat(root); err != nil {
 			return err
 		}
-		filename := path.Join(root, "Dockerfile")
-		if _, err = os.Stat(filename); os.IsNotExist(err) {
-			return fmt.Errorf("no Dockerfile found in %s", cmd.Arg(0))
+		if *dockerfileName != "" && !isGit && dockerfile == nil {
+			// a local Dockerfile is relative to the current directory, the
+			// ones out of the context are sent separately
+			rel, err := relativeDockerfile(root, *dockerfileName)
+			if err != nil {
+				return err
+			}
+			if rel == "" {
+				if dockerfile, err = ioutil.ReadFile(*dockerfileName); err != nil {
+					return err
+				}
+			}
+			*dockerfileName = rel
 		}
-		var excludes []string
-		ignore, err := ioutil.ReadFile(path.Join(root, ".dockerignore"))
-		if err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("Error reading .dockerignore: '%s'", err)
+		filename := *dockerfileName
+		if filename == "" {
+			filename = "Dockerfile"
 		}
-		for _, pattern := range strings.Split(string(ignore), "\n") {
-			pattern = strings.TrimSpace(pattern)
-			if pattern == "" {
-				continue
+		if dockerfile == nil {
+			if _, err = os.Stat(path.Join(root, filename)); os.IsNotExist(err) {
+				if *dockerfileName != "" {
+					return fmt.Errorf("Cannot locate specified Dockerfile: %s", filename)
+				}
+				return fmt.Errorf("no Dockerfile found in %s", cmd.Arg(0))
 			}
-			pattern = filepath.Clean(pattern)
-			ok, err := filepath.Match(pattern, "Dockerfile")
+		}
+		var excludes []string
+		if f, err := os.Open(path.Join(root, ".dockerignore")); err == nil {
+			excludes, err = fileutils.ReadPatterns(f)
+			f.Close()
 			if err != nil {
-				return fmt.Errorf("Bad .dockerignore pattern: '%s', error: %s", pattern, err)
+				return err
 			}
-			if ok {
-				return fmt.Errorf("Dockerfile was excluded by .dockerignore pattern '%s'", pattern)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("Error reading .dockerignore: '%s'", err)
+		}
+		// the daemon needs these, it removes them from the context itself
+		// when they are excluded
+		for _, name := range []string{filename, ".dockerignore"} {
+			if skip, _ := fileutils.Matches(name, excludes); skip {
+				excludes = append(excludes, "!"+name)
 			}
-			excludes = append(excludes, pattern)
 		}
 		if err = utils.ValidateContextDirectory(root, excludes); err != nil {
 			return fmt.Errorf("Error checking context is accessible: '%s'. Please check permissions and try again.", err)
@@ -174,6 +217,10 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 		if err != nil {
 			return err
 		}
+		if dockerfile != nil {
+			*dockerfileName = ".dockerfile." + utils.GenerateRandomID()[:20]
+			context = addDockerfileToContext(context, *dockerfileName, dockerfile)
+		}
 	}
 	var body io.Reader
 	// Setup an upload progress bar
@@ -222,6 +269,46 @@ func (cli *DockerCli) CmdBuild(args ...string) error {
 	if *pull {
 		v.Set("pull", "1")
 	}
+
+	if *squash {
+		v.Set("squash", "1")
+	}
+	if *check {
+		v.Set("check", "1")
+	}
+
+	// the steps are rendered as text by DisplayJSONMessagesStream
+	v.Set("steps", "1")
+
+	if *dockerfileName != "" {
+		v.Set("dockerfile", *dockerfileName)
+	}
+
+	if *target != "" {
+		v.Set("target", *target)
+	}
+
+	if flCacheFrom.Len() > 0 {
+		buf, err := json.Marshal(flCacheFrom.GetAll())
+		if err != nil {
+			return err
+		}
+		v.Set("cachefrom", string(buf))
+	}
+
+	if flBuildArgs.Len() > 0 {
+		buildArgs := map[string]string{}
+		// a name without value is taken from the environment of the client
+		for _, arg := range flBuildArgs.GetAll() {
+			parts := strings.SplitN(arg, "=", 2)
+			buildArgs[parts[0]] = parts[1]
+		}
+		buf, err := json.Marshal(buildArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("buildargs", string(buf))
+	}
 	cli.LoadConfigFile()
 
 	headers := http.Header(make(map[string][]string))
@@ -1596,7 +1683,7 @@ func (cli *DockerCli) CmdPs(args ...string) error {
 		flFilter = opts.NewListOpts(nil)
 	)
 
-	cmd.Var(&flFilter, []string{"f", "-filter"}, "Provide filter values. Valid filters:\nexited=<int> - containers with exit code of <int>\nstatus=(restarting|running|paused|exited)")
+	cmd.Var(&flFilter, []string{"f", "-filter"}, "Provide filter values. Valid filters:\nexited=<int> - containers with exit code of <int>\nstatus=(restarting|running|paused|exited)\nlabel=<key> or label=<key>=<value> - containers with the label")
 
 	if err := cmd.Parse(args); err != nil {
 		return nil
@@ -2353,7 +2440,7 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		}()
 	}
 
-	if *flAutoRemove && (hostConfig.RestartPolicy.Name == "always" || hostConfig.RestartPolicy.Name == "on-failure") {
+	if *flAutoRemove && hostConfig.RestartPolicy.Name != "" {
 		return ErrConflictRestartPolicyAndAutoRemove
 	}
 
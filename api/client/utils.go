@@ -12,8 +12,10 @@ import (
 	"net/url"
 	"os"
 	gosignal "os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
@@ -24,6 +26,7 @@ import (
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
 )
 
 var (
@@ -294,3 +297,93 @@ func readBody(stream io.ReadCloser, statusCode int, err error) ([]byte, int, err
 	}
 	return body, statusCode, nil
 }
+
+// relativeDockerfile returns the path of the Dockerfile given with -f,
+// relative to the current directory, inside the context root. It returns ""
+// if the Dockerfile is out of the context.
+func relativeDockerfile(root, dockerfile string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absDockerfile, err := filepath.Abs(dockerfile)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(absDockerfile); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("Cannot locate specified Dockerfile: %s", dockerfile)
+		}
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absDockerfile)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", nil
+	}
+	return rel, nil
+}
+
+// addDockerfileToContext adds a Dockerfile sent separately from the context
+// to the context tar, under name. The name is added to the .dockerignore of
+// the context so that the daemon removes it before the build.
+func addDockerfileToContext(context io.ReadCloser, name string, dockerfile []byte) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		defer context.Close()
+		tr := tar.NewReader(context)
+		tw := tar.NewWriter(w)
+		ignoreHdr := &tar.Header{Name: ".dockerignore", Mode: 0644}
+		ignore := []byte(".dockerignore\n")
+		err := func() error {
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				if filepath.Clean(hdr.Name) == ".dockerignore" {
+					if ignore, err = ioutil.ReadAll(tr); err != nil {
+						return err
+					}
+					ignoreHdr = hdr
+					continue
+				}
+				if err := tw.WriteHeader(hdr); err != nil {
+					return err
+				}
+				if _, err := io.Copy(tw, tr); err != nil {
+					return err
+				}
+			}
+			if len(ignore) > 0 && ignore[len(ignore)-1] != '\n' {
+				ignore = append(ignore, '\n')
+			}
+			ignore = append(ignore, name+"\n"...)
+			now := time.Now()
+			files := []struct {
+				hdr     *tar.Header
+				content []byte
+			}{
+				{&tar.Header{Name: name, Mode: 0644, ModTime: now}, dockerfile},
+				{ignoreHdr, ignore},
+			}
+			for _, f := range files {
+				f.hdr.Size = int64(len(f.content))
+				if f.hdr.ModTime.IsZero() {
+					f.hdr.ModTime = now
+				}
+				if err := tw.WriteHeader(f.hdr); err != nil {
+					return err
+				}
+				if _, err := tw.Write(f.content); err != nil {
+					return err
+				}
+			}
+			return tw.Close()
+		}()
+		w.CloseWithError(err)
+	}()
+	return r
+}
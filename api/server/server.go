@@ -1028,6 +1028,15 @@ func postBuild(eng *engine.Engine, version version.Version, w http.ResponseWrite
 	if r.FormValue("pull") == "1" && version.GreaterThanOrEqualTo("1.16") {
 		job.Setenv("pull", "1")
 	}
+	if version.GreaterThanOrEqualTo("1.16") {
+		job.Setenv("buildargs", r.FormValue("buildargs"))
+		job.Setenv("dockerfile", r.FormValue("dockerfile"))
+		job.Setenv("target", r.FormValue("target"))
+		job.Setenv("cachefrom", r.FormValue("cachefrom"))
+		job.Setenv("steps", r.FormValue("steps"))
+		job.Setenv("squash", r.FormValue("squash"))
+		job.Setenv("check", r.FormValue("check"))
+	}
 	job.Stdin.Add(r.Body)
 	job.Setenv("remote", r.FormValue("remote"))
 	job.Setenv("t", r.FormValue("t"))
@@ -12,11 +12,13 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/nat"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -78,6 +80,62 @@ func maintainer(b *Builder, args []string, attributes map[string]bool, original
 	return b.commit("", b.Config.Cmd, fmt.Sprintf("MAINTAINER %s", b.maintainer))
 }
 
+// LABEL some.key=value other.key="other value"
+//
+// Sets metadata on the image, also accepts the LABEL key value form.
+//
+func label(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("LABEL is missing arguments")
+	}
+
+	if len(args)%2 != 0 {
+		// should never get here, but just in case
+		return fmt.Errorf("Bad input to LABEL, too many args")
+	}
+
+	commitStr := "LABEL"
+
+	if b.Config.Labels == nil {
+		b.Config.Labels = map[string]string{}
+	}
+
+	for j := 0; j < len(args); j += 2 {
+		// name  ==> args[j]
+		// value ==> args[j+1]
+		commitStr += " " + args[j] + "=" + args[j+1]
+		b.Config.Labels[args[j]] = args[j+1]
+	}
+
+	return b.commit("", b.Config.Cmd, commitStr)
+}
+
+// ARG name[=default]
+//
+// Declares a build-time variable which can be set with --build-arg. It is
+// available to the interpolation of the next statements and in the
+// environment of RUN, but it is not kept in the environment of the image.
+//
+func arg(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return fmt.Errorf("ARG requires exactly one argument")
+	}
+
+	name := args[0]
+	commitStr := "ARG " + name
+	b.argsDecl[name] = struct{}{}
+	if value, ok := b.BuildArgs[name]; ok {
+		b.argsSet[name] = value
+	} else if len(args) == 2 {
+		b.argsSet[name] = args[1]
+	}
+	if len(args) == 2 {
+		commitStr += "=" + args[1]
+	}
+
+	return b.commit("", b.Config.Cmd, commitStr)
+}
+
 // ADD foo /path
 //
 // Add the file 'foo' to '/path'. Tarball and Remote URL (git, http) handling
@@ -88,51 +146,65 @@ func add(b *Builder, args []string, attributes map[string]bool, original string)
 		return fmt.Errorf("ADD requires at least two arguments")
 	}
 
-	return b.runContextCommand(args, true, true, "ADD")
+	return b.runContextCommand(args, true, true, "ADD", "")
 }
 
-// COPY foo /path
+// COPY [--from=<stage|image>] foo /path
 //
-// Same as 'ADD' but without the tar and remote url handling.
+// Same as 'ADD' but without the tar and remote url handling. With --from the
+// sources are taken from the rootfs of a previous stage or of an image
+// instead of the context.
 //
 func dispatchCopy(b *Builder, args []string, attributes map[string]bool, original string) error {
+	from := ""
+	if len(args) > 0 && strings.HasPrefix(args[0], "--from=") {
+		from = strings.TrimPrefix(args[0], "--from=")
+		if from == "" {
+			return fmt.Errorf("COPY --from requires a stage or an image")
+		}
+		args = args[1:]
+	}
+
 	if len(args) < 2 {
 		return fmt.Errorf("COPY requires at least two arguments")
 	}
 
-	return b.runContextCommand(args, false, false, "COPY")
+	return b.runContextCommand(args, false, false, "COPY", from)
 }
 
-// FROM imagename
+// FROM imagename [AS name]
 //
-// This sets the image the dockerfile will build on top of.
+// This sets the image the dockerfile will build on top of. Every FROM starts
+// a new stage, which can be named to be used by a later FROM or COPY --from.
+// Only the image of the last stage is the result of the build.
 //
 func from(b *Builder, args []string, attributes map[string]bool, original string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("FROM requires one argument")
+	if len(args) != 1 && (len(args) != 3 || !strings.EqualFold(args[1], "as")) {
+		return fmt.Errorf("FROM requires either one argument, or three: FROM <image> AS <name>")
 	}
 
-	name := args[0]
+	// a previous stage is complete
+	if b.image != "" {
+		b.endStage()
+	}
 
-	image, err := b.Daemon.Repositories().LookupImage(name)
-	if b.Pull {
-		image, err = b.pullImage(name)
-		if err != nil {
-			return err
+	stageName := ""
+	if len(args) == 3 {
+		stageName = strings.ToLower(args[2])
+		if !validStageName.MatchString(stageName) {
+			return fmt.Errorf("Invalid stage name %q, it must start with a letter and contain only letters, digits, '_', '-' and '.'", args[2])
 		}
-	}
-	if err != nil {
-		if b.Daemon.Graph().IsNotExist(err) {
-			image, err = b.pullImage(name)
+		if _, ok := b.stageNames[stageName]; ok {
+			return fmt.Errorf("Duplicate stage name %q", stageName)
 		}
+	}
 
-		// note that the top level err will still be !nil here if IsNotExist is
-		// not the error. This approach just simplifies hte logic a bit.
-		if err != nil {
-			return err
-		}
+	image, err := b.stageImage(args[0], false)
+	if err != nil {
+		return err
 	}
 
+	b.stageName = stageName
 	return b.processImageFrom(image)
 }
 
@@ -186,7 +258,8 @@ func workdir(b *Builder, args []string, attributes map[string]bool, original str
 // RUN some command yo
 //
 // run a command and commit the image. Args are automatically prepended with
-// 'sh -c' in the event there is only one argument. The difference in
+// 'sh -c', or the shell set with SHELL, in the event there is only one
+// argument. The difference in
 // processing:
 //
 // RUN echo hi          # sh -c echo hi
@@ -200,7 +273,7 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 	args = handleJsonArgs(args, attributes)
 
 	if len(args) == 1 {
-		args = append([]string{"/bin/sh", "-c"}, args[0])
+		args = b.shellForm(args[0])
 	}
 
 	runCmd := flag.NewFlagSet("run", flag.ContinueOnError)
@@ -219,6 +292,15 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 
 	defer func(cmd []string) { b.Config.Cmd = cmd }(cmd)
 
+	// the build args are in the environment of the container, and thus part
+	// of the cache key, but not in the config of the image
+	env := b.Config.Env
+	if argsEnv := b.buildArgsEnv(); len(argsEnv) > 0 {
+		b.Config.Env = append(append([]string{}, env...), argsEnv...)
+	}
+
+	defer func(env []string) { b.Config.Env = env }(env)
+
 	log.Debugf("[BUILDER] Command to be executed: %v", b.Config.Cmd)
 
 	hit, err := b.probeCache()
@@ -234,6 +316,11 @@ func run(b *Builder, args []string, attributes map[string]bool, original string)
 		return err
 	}
 
+	// the container keeps its own config, with the build args
+	imageConfig := *b.Config
+	imageConfig.Env = env
+	b.Config = &imageConfig
+
 	// Ensure that we keep the container mounted until the commit
 	// to avoid unmounting and then mounting directly again
 	c.Mount()
@@ -259,7 +346,7 @@ func cmd(b *Builder, args []string, attributes map[string]bool, original string)
 	b.Config.Cmd = handleJsonArgs(args, attributes)
 
 	if !attributes["json"] {
-		b.Config.Cmd = append([]string{"/bin/sh", "-c"}, b.Config.Cmd...)
+		b.Config.Cmd = b.shellForm(b.Config.Cmd...)
 	}
 
 	if err := b.commit("", b.Config.Cmd, fmt.Sprintf("CMD %v", b.Config.Cmd)); err != nil {
@@ -293,7 +380,7 @@ func entrypoint(b *Builder, args []string, attributes map[string]bool, original
 		b.Config.Entrypoint = nil
 	default:
 		// ENTRYPOINT echo hi
-		b.Config.Entrypoint = []string{"/bin/sh", "-c", parsed[0]}
+		b.Config.Entrypoint = b.shellForm(parsed[0])
 	}
 
 	// when setting the entrypoint if a CMD was not explicitly set then
@@ -371,6 +458,121 @@ func volume(b *Builder, args []string, attributes map[string]bool, original stri
 	return nil
 }
 
+// STOPSIGNAL SIGQUIT
+//
+// Set the signal sent by docker stop to the containers of the image, instead
+// of SIGTERM.
+//
+func stopSignal(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("STOPSIGNAL requires exactly one argument")
+	}
+
+	if _, err := signal.ParseSignal(args[0]); err != nil {
+		return err
+	}
+
+	b.Config.StopSignal = args[0]
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("STOPSIGNAL %v", args))
+}
+
+// SHELL ["/bin/bash", "-c"]
+//
+// Set the shell which runs the shell form of RUN, CMD and ENTRYPOINT, and the
+// CMD health checks, instead of /bin/sh -c. Only the JSON array form is
+// accepted.
+//
+func shell(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if !attributes["json"] {
+		return fmt.Errorf("SHELL requires the arguments to be in JSON form")
+	}
+	if len(args) == 0 || args[0] == "" {
+		return fmt.Errorf("SHELL requires at least one argument")
+	}
+
+	b.Config.Shell = args
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("SHELL %v", args))
+}
+
+// HEALTHCHECK [--interval=30s] [--timeout=30s] [--retries=3] CMD curl -f http://localhost/
+//
+// Set the health check of the containers of the image, like the --health-*
+// options of docker run. The check is one of:
+//
+// HEALTHCHECK CMD command          # run command inside the container
+// HEALTHCHECK TCP port             # connect to port
+// HEALTHCHECK HTTP port[/path]     # expect a 2xx or 3xx answer to a GET of path
+// HEALTHCHECK NONE                 # disable the health check of the base image
+//
+func healthcheck(b *Builder, args []string, attributes map[string]bool, original string) error {
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.Usage = nil
+	interval := flags.Duration([]string{"-interval"}, 0, "")
+	timeout := flags.Duration([]string{"-timeout"}, 0, "")
+	retries := flags.Int([]string{"-retries"}, 0, "")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("HEALTHCHECK: %v", err)
+	}
+	if *interval < 0 || *timeout < 0 || *retries < 0 {
+		return fmt.Errorf("HEALTHCHECK --interval, --timeout and --retries cannot be negative")
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		return fmt.Errorf("HEALTHCHECK requires a check: CMD, TCP, HTTP or NONE")
+	}
+
+	var test []string
+	switch typ := strings.ToUpper(args[0]); typ {
+	case "NONE":
+		if len(args) != 1 || flags.NFlag() != 0 {
+			return fmt.Errorf("HEALTHCHECK NONE takes no arguments")
+		}
+		test = []string{"NONE"}
+	case "CMD":
+		command := handleJsonArgs(args[1:], attributes)
+		if len(command) == 0 || command[0] == "" {
+			return fmt.Errorf("HEALTHCHECK CMD requires a command")
+		}
+		if attributes["json"] {
+			test = append([]string{"CMD"}, command...)
+		} else {
+			test = []string{"CMD-SHELL", command[0]}
+		}
+	case "TCP", "HTTP":
+		if len(args) != 2 {
+			return fmt.Errorf("HEALTHCHECK %s requires exactly one argument", typ)
+		}
+		parts := strings.SplitN(args[1], "/", 2)
+		if _, err := strconv.ParseUint(parts[0], 10, 16); err != nil {
+			return fmt.Errorf("Invalid HEALTHCHECK %s port: %s", typ, parts[0])
+		}
+		if typ == "TCP" {
+			if len(parts) != 1 {
+				return fmt.Errorf("Invalid HEALTHCHECK TCP port: %s", args[1])
+			}
+			test = []string{"TCP", parts[0]}
+		} else {
+			path := "/"
+			if len(parts) == 2 {
+				path += parts[1]
+			}
+			test = []string{"HTTP", parts[0], path}
+		}
+	default:
+		return fmt.Errorf("Unknown HEALTHCHECK type %s, expected CMD, TCP, HTTP or NONE", args[0])
+	}
+
+	b.Config.Healthcheck = &runconfig.HealthConfig{
+		Test:     test,
+		Interval: *interval,
+		Timeout:  *timeout,
+		Retries:  *retries,
+	}
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("HEALTHCHECK %v", test))
+}
+
 // INSERT is no longer accepted, but we still parse it.
 func insert(b *Builder, args []string, attributes map[string]bool, original string) error {
 	return fmt.Errorf("INSERT has been deprecated. Please use ADD instead")
@@ -24,13 +24,17 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/builder/lint"
 	"github.com/docker/docker/builder/parser"
 	"github.com/docker/docker/daemon"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/tarsum"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/runconfig"
@@ -41,35 +45,29 @@ var (
 	ErrDockerfileEmpty = errors.New("Dockerfile cannot be empty")
 )
 
-// Environment variable interpolation will happen on these statements only.
-var replaceEnvAllowed = map[string]struct{}{
-	"env":     {},
-	"add":     {},
-	"copy":    {},
-	"workdir": {},
-	"expose":  {},
-	"volume":  {},
-	"user":    {},
-}
-
 var evaluateTable map[string]func(*Builder, []string, map[string]bool, string) error
 
 func init() {
 	evaluateTable = map[string]func(*Builder, []string, map[string]bool, string) error{
-		"env":        env,
-		"maintainer": maintainer,
-		"add":        add,
-		"copy":       dispatchCopy, // copy() is a go builtin
-		"from":       from,
-		"onbuild":    onbuild,
-		"workdir":    workdir,
-		"run":        run,
-		"cmd":        cmd,
-		"entrypoint": entrypoint,
-		"expose":     expose,
-		"volume":     volume,
-		"user":       user,
-		"insert":     insert,
+		"env":         env,
+		"maintainer":  maintainer,
+		"label":       label,
+		"arg":         arg,
+		"add":         add,
+		"copy":        dispatchCopy, // copy() is a go builtin
+		"from":        from,
+		"onbuild":     onbuild,
+		"workdir":     workdir,
+		"run":         run,
+		"cmd":         cmd,
+		"entrypoint":  entrypoint,
+		"expose":      expose,
+		"volume":      volume,
+		"user":        user,
+		"stopsignal":  stopSignal,
+		"shell":       shell,
+		"healthcheck": healthcheck,
+		"insert":      insert,
 	}
 }
 
@@ -101,15 +99,46 @@ type Builder struct {
 
 	Config *runconfig.Config // runconfig for cmd, run, entrypoint etc.
 
+	// values of the --build-arg options, only the ones declared with ARG
+	// in the Dockerfile are used
+	BuildArgs map[string]string
+
+	// path of the Dockerfile in the context, "Dockerfile" when empty
+	DockerfileName string
+
+	// name of the stage to stop the build at, the last stage when empty
+	Target string
+
+	// images, with their parents, to use as the build cache instead of all
+	// the local images
+	CacheFrom []string
+
+	// send the steps as JSON events rather than as text, when the
+	// StreamFormatter is JSON
+	Steps bool
+
+	// squash the layers created by the build into one layer on top of the
+	// image of the last FROM
+	Squash bool
+
 	// both of these are controlled by the Remove and ForceRemove options in BuildOpts
 	TmpContainers map[string]struct{} // a map of containers used for removes
 
-	dockerfile  *parser.Node  // the syntax tree of the dockerfile
-	image       string        // image name for commit processing
-	maintainer  string        // maintainer name. could probably be removed.
-	cmdSet      bool          // indicates is CMD was set in current Dockerfile
-	context     tarsum.TarSum // the context is a tarball that is uploaded by the client
-	contextPath string        // the path of the temporary directory the local context is unpacked to (server side)
+	dockerfile  *parser.Node        // the syntax tree of the dockerfile
+	image       string              // image name for commit processing
+	fromImage   string              // the image of the last FROM, before its ONBUILD triggers
+	maintainer  string              // maintainer name. could probably be removed.
+	cmdSet      bool                // indicates is CMD was set in current Dockerfile
+	context     tarsum.TarSum       // the context is a tarball that is uploaded by the client
+	contextPath string              // the path of the temporary directory the local context is unpacked to (server side)
+	argsSet     map[string]string   // the ARG variables declared so far which have a value
+	argsDecl    map[string]struct{} // the names of all the ARG variables declared so far
+	stageName   string              // the name of the current stage, given with FROM ... AS
+	stageNames  map[string]int      // the index of the named stages in stageImages
+	stageImages []string            // the image IDs of the completed stages
+	cacheFrom   map[string]struct{} // the image IDs of the cache given with CacheFrom
+	step        *utils.JSONStep     // the done event of the current step, filled as it runs
+	inTrigger   bool                // the ONBUILD triggers of the current step are dispatched
 
 }
 
@@ -136,42 +165,44 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 		}
 	}()
 
-	filename := path.Join(b.contextPath, "Dockerfile")
-
-	fi, err := os.Stat(filename)
-	if os.IsNotExist(err) {
-		return "", fmt.Errorf("Cannot build a directory without a Dockerfile")
-	}
-	if fi.Size() == 0 {
-		return "", ErrDockerfileEmpty
-	}
-
-	f, err := os.Open(filename)
+	ast, err := b.readDockerfile()
 	if err != nil {
 		return "", err
 	}
 
-	defer f.Close()
-
-	ast, err := parser.Parse(f)
-	if err != nil {
+	if err := b.applyDockerignore(); err != nil {
 		return "", err
 	}
 
+	if b.Target != "" {
+		if ast.Children, err = stopAtTarget(ast.Children, b.Target); err != nil {
+			return "", err
+		}
+	}
+
 	b.dockerfile = ast
 
 	// some initializations that would not have been supplied by the caller.
 	b.Config = &runconfig.Config{}
 	b.TmpContainers = map[string]struct{}{}
+	b.argsSet = map[string]string{}
+	b.argsDecl = map[string]struct{}{}
+	b.stageNames = map[string]int{}
+	if err := b.loadCacheFrom(); err != nil {
+		return "", err
+	}
 
 	for i, n := range b.dockerfile.Children {
+		start := time.Now()
 		if err := b.dispatch(i, n); err != nil {
 			if b.ForceRemove {
 				b.clearTmp()
 			}
-			return "", err
+			return "", lineError(n, err)
 		}
-		fmt.Fprintf(b.OutStream, " ---> %s\n", utils.TruncateID(b.image))
+		b.step.ImageID = b.image
+		b.step.Duration = time.Since(start)
+		b.emitStep(b.step)
 		if b.Remove {
 			b.clearTmp()
 		}
@@ -181,10 +212,128 @@ func (b *Builder) Run(context io.Reader) (string, error) {
 		return "", fmt.Errorf("No image was generated. Is your Dockerfile empty?\n")
 	}
 
+	unused := []string{}
+	for name := range b.BuildArgs {
+		if _, ok := b.argsDecl[name]; !ok {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", fmt.Errorf("One or more build-args %v were not consumed, failing build.", unused)
+	}
+
+	if b.Squash {
+		img, err := b.Daemon.Squash(b.image, b.fromImage)
+		if err != nil {
+			return "", err
+		}
+		if img.ID != b.image {
+			fmt.Fprintf(b.OutStream, "Squashed the layers of %s into %s\n", utils.TruncateID(b.image), utils.TruncateID(img.ID))
+			b.image = img.ID
+		}
+	}
+
 	fmt.Fprintf(b.OutStream, "Successfully built %s\n", utils.TruncateID(b.image))
 	return b.image, nil
 }
 
+// stopAtTarget drops the stages which follow the stage named target.
+func stopAtTarget(nodes []*parser.Node, target string) ([]*parser.Node, error) {
+	found := false
+	for i, n := range nodes {
+		if n.Value != "from" {
+			continue
+		}
+		if found {
+			return nodes[:i], nil
+		}
+		// FROM <image> AS <name>
+		if n.Next != nil && n.Next.Next != nil && n.Next.Next.Next != nil {
+			found = strings.EqualFold(n.Next.Next.Next.Value, target)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("The target stage %s could not be found", target)
+	}
+	return nodes, nil
+}
+
+// Check reads the Dockerfile of the context like Run, and reports its issues
+// instead of building it. An error is returned if there is any issue.
+func (b *Builder) Check(context io.Reader) error {
+	if err := b.readContext(context); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := os.RemoveAll(b.contextPath); err != nil {
+			log.Debugf("[BUILDER] failed to remove temporary context: %s", err)
+		}
+	}()
+
+	ast, err := b.readDockerfile()
+	if err != nil {
+		return err
+	}
+
+	issues := lint.Check(ast)
+	for _, issue := range issues {
+		fmt.Fprintf(b.OutStream, "%s\n", issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("Found %d issue(s) in the Dockerfile", len(issues))
+	}
+	fmt.Fprintf(b.OutStream, "No issues found in the Dockerfile\n")
+	return nil
+}
+
+// lineError returns err with the lines of the instruction in the Dockerfile,
+// unless it already has lines.
+func lineError(ast *parser.Node, err error) error {
+	if _, ok := err.(*parser.LineError); ok {
+		return err
+	}
+	return &parser.LineError{StartLine: ast.StartLine, EndLine: ast.EndLine, Err: err}
+}
+
+// readDockerfile parses the Dockerfile of the context.
+func (b *Builder) readDockerfile() (*parser.Node, error) {
+	name := b.DockerfileName
+	if name == "" {
+		name = "Dockerfile"
+	}
+	filename, err := symlink.FollowSymlinkInScope(filepath.Join(b.contextPath, name), b.contextPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		if b.DockerfileName != "" {
+			return nil, fmt.Errorf("Cannot locate specified Dockerfile: %s", b.DockerfileName)
+		}
+		return nil, fmt.Errorf("Cannot build a directory without a Dockerfile")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("The Dockerfile %s is a directory", name)
+	}
+	if fi.Size() == 0 {
+		return nil, ErrDockerfileEmpty
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parser.Parse(f)
+}
+
 // This method is the entrypoint to all statement handling routines.
 //
 // Almost all nodes will have this structure:
@@ -204,26 +353,32 @@ func (b *Builder) dispatch(stepN int, ast *parser.Node) error {
 	attrs := ast.Attributes
 	original := ast.Original
 	strs := []string{}
-	msg := fmt.Sprintf("Step %d : %s", stepN, strings.ToUpper(cmd))
+	instruction := strings.ToUpper(cmd)
 
 	if cmd == "onbuild" {
 		ast = ast.Next.Children[0]
 		strs = append(strs, ast.Value)
-		msg += " " + ast.Value
+		instruction += " " + ast.Value
 	}
 
 	for ast.Next != nil {
 		ast = ast.Next
 		var str string
 		str = ast.Value
-		if _, ok := replaceEnvAllowed[cmd]; ok {
+		if _, ok := parser.ReplaceEnvAllowed[cmd]; ok {
 			str = b.replaceEnv(ast.Value)
 		}
 		strs = append(strs, str)
-		msg += " " + ast.Value
+		instruction += " " + ast.Value
 	}
 
-	fmt.Fprintln(b.OutStream, msg)
+	if b.inTrigger {
+		// the triggers are part of the current step
+		b.emitStep(&utils.JSONStep{Index: b.step.Index, Event: utils.StepStart, Instruction: instruction, Trigger: true})
+	} else {
+		b.step = &utils.JSONStep{Index: stepN, Event: utils.StepDone, Instruction: instruction}
+		b.emitStep(&utils.JSONStep{Index: stepN, Event: utils.StepStart, Instruction: instruction})
+	}
 
 	// XXX yes, we skip any cmds that are not valid; the parser should have
 	// picked these out already.
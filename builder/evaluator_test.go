@@ -0,0 +1,31 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/builder/parser"
+)
+
+func TestLineError(t *testing.T) {
+	ast, err := parser.Parse(strings.NewReader("FROM busybox\n\nRUN make \\\n  install\nCMD make"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = lineError(ast.Children[1], errors.New("The command [/bin/sh -c make install] returned a non-zero code: 2"))
+	if expected := "Dockerfile lines 3-4: The command [/bin/sh -c make install] returned a non-zero code: 2"; err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err)
+	}
+	err = lineError(ast.Children[2], errors.New("failed"))
+	if expected := "Dockerfile line 5: failed"; err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err)
+	}
+
+	// an error which already has lines is not wrapped again
+	lerr := &parser.LineError{StartLine: 1, EndLine: 1, Err: errors.New("failed")}
+	if err := lineError(ast.Children[2], lerr); err != lerr {
+		t.Fatalf("Expected the error as is, got %q", err)
+	}
+}
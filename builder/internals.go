@@ -4,6 +4,7 @@ package builder
 // non-contiguous functionality. Please read the comments.
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -14,7 +15,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -25,12 +28,14 @@ import (
 	imagepkg "github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/pkg/tarsum"
 	"github.com/docker/docker/pkg/urlutil"
 	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
 
@@ -57,6 +62,83 @@ func (b *Builder) readContext(context io.Reader) error {
 	return nil
 }
 
+// applyDockerignore removes the files excluded by the .dockerignore file from
+// the context, whatever its source. The Dockerfile and the .dockerignore file
+// themselves are removed too when they are excluded, as they are read first.
+func (b *Builder) applyDockerignore() error {
+	f, err := os.Open(path.Join(b.contextPath, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	excludes, err := fileutils.ReadPatterns(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if len(excludes) == 0 {
+		return nil
+	}
+
+	var (
+		removed    bool
+		dirs       []string // excluded directories kept for their exceptions
+		exceptions = fileutils.HasExceptions(excludes)
+	)
+	err = filepath.Walk(b.contextPath, func(filePath string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relFilePath, err := filepath.Rel(b.contextPath, filePath)
+		if err != nil || relFilePath == "." {
+			return err
+		}
+		if skip, err := fileutils.Matches(relFilePath, excludes); err != nil || !skip {
+			return err
+		}
+		removed = true
+		if f.IsDir() {
+			if exceptions {
+				dirs = append(dirs, filePath)
+				return nil
+			}
+			if err := os.RemoveAll(filePath); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		return os.Remove(filePath)
+	})
+	if err != nil {
+		return err
+	}
+	// the deepest first, the ones with files included again are not empty
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+	if !removed {
+		return nil
+	}
+
+	// the checksums of the context must not cover the excluded files
+	context, err := archive.Tar(b.contextPath, archive.Uncompressed)
+	if err != nil {
+		return err
+	}
+	defer context.Close()
+	ts, err := tarsum.NewTarSum(context, true, tarsum.Version0)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(ioutil.Discard, ts); err != nil {
+		return err
+	}
+	b.context = ts
+	return nil
+}
+
 func (b *Builder) commit(id string, autoCmd []string, comment string) error {
 	if b.image == "" {
 		return fmt.Errorf("Please provide a source image with `from` prior to commit")
@@ -112,8 +194,15 @@ type copyInfo struct {
 	tmpDir     string
 }
 
-func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecompression bool, cmdName string) error {
-	if b.context == nil {
+// copySource is where the sources of ADD and COPY are found, the context or
+// the rootfs of the image given with COPY --from
+type copySource struct {
+	root  string // path of the sources on the host
+	image string // ID of the image, empty for the context
+}
+
+func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecompression bool, cmdName string, from string) error {
+	if b.context == nil && from == "" {
 		return fmt.Errorf("No context given. Impossible to use %s", cmdName)
 	}
 
@@ -121,6 +210,21 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 		return fmt.Errorf("Invalid %s format - at least two arguments required", cmdName)
 	}
 
+	src := copySource{root: b.contextPath}
+	if from != "" {
+		img, err := b.stageImage(from, true)
+		if err != nil {
+			return err
+		}
+		driver := b.Daemon.GraphDriver()
+		root, err := driver.Get(img.ID, "")
+		if err != nil {
+			return err
+		}
+		defer driver.Put(img.ID)
+		src = copySource{root: root, image: img.ID}
+	}
+
 	dest := args[len(args)-1] // last one is always the dest
 
 	copyInfos := []*copyInfo{}
@@ -139,7 +243,7 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 	// do the copy (e.g. hash value if cached).  Don't actually do
 	// the copy until we've looked at all src files
 	for _, orig := range args[0 : len(args)-1] {
-		err := calcCopyInfo(b, cmdName, &copyInfos, orig, dest, allowRemote, allowDecompression)
+		err := calcCopyInfo(b, cmdName, &copyInfos, orig, dest, allowRemote, allowDecompression, src)
 		if err != nil {
 			return err
 		}
@@ -199,7 +303,7 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 	defer container.Unmount()
 
 	for _, ci := range copyInfos {
-		if err := b.addContext(container, ci.origPath, ci.destPath, ci.decompress); err != nil {
+		if err := b.addContext(container, src.root, ci.origPath, ci.destPath, ci.decompress); err != nil {
 			return err
 		}
 	}
@@ -210,7 +314,7 @@ func (b *Builder) runContextCommand(args []string, allowRemote bool, allowDecomp
 	return nil
 }
 
-func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath string, destPath string, allowRemote bool, allowDecompression bool) error {
+func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath string, destPath string, allowRemote bool, allowDecompression bool, src copySource) error {
 
 	if origPath != "" && origPath[0] == '/' && len(origPath) > 1 {
 		origPath = origPath[1:]
@@ -316,6 +420,12 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 		return nil
 	}
 
+	// The sources of another image are resolved in its rootfs, and are
+	// identified by the image for the cache
+	if src.image != "" {
+		return calcImageCopyInfo(cInfos, origPath, destPath, src)
+	}
+
 	// Deal with wildcards
 	if ContainsWildcards(origPath) {
 		for _, fileInfo := range b.context.GetSums() {
@@ -327,7 +437,7 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 				continue
 			}
 
-			calcCopyInfo(b, cmdName, cInfos, fileInfo.Name(), destPath, allowRemote, allowDecompression)
+			calcCopyInfo(b, cmdName, cInfos, fileInfo.Name(), destPath, allowRemote, allowDecompression, src)
 		}
 		return nil
 	}
@@ -390,6 +500,48 @@ func calcCopyInfo(b *Builder, cmdName string, cInfos *[]*copyInfo, origPath stri
 	return nil
 }
 
+func calcImageCopyInfo(cInfos *[]*copyInfo, origPath string, destPath string, src copySource) error {
+	if ContainsWildcards(origPath) {
+		matches, err := filepath.Glob(path.Join(src.root, origPath))
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(src.root, match)
+			if err != nil {
+				return err
+			}
+			if err := calcImageCopyInfo(cInfos, rel, destPath, src); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// symlinks are resolved in the rootfs, not on the host
+	resolved, err := symlink.FollowSymlinkInScope(path.Join(src.root, origPath), src.root)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(resolved); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s: no such file or directory", origPath)
+		}
+		return err
+	}
+	rel, err := filepath.Rel(src.root, resolved)
+	if err != nil {
+		return err
+	}
+
+	*cInfos = append(*cInfos, &copyInfo{
+		origPath: rel,
+		hash:     "image:" + src.image + ":" + rel,
+		destPath: destPath,
+	})
+	return nil
+}
+
 func ContainsWildcards(name string) bool {
 	for i := 0; i < len(name); i++ {
 		ch := name[i]
@@ -402,6 +554,64 @@ func ContainsWildcards(name string) bool {
 	return false
 }
 
+// validStageName matches the names of the stages given with FROM ... AS
+var validStageName = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+// endStage records the image of the stage which is complete, and resets the
+// state of the builder for the next one
+func (b *Builder) endStage() {
+	if b.stageName != "" {
+		b.stageNames[b.stageName] = len(b.stageImages)
+	}
+	b.stageImages = append(b.stageImages, b.image)
+	b.stageName = ""
+	b.image = ""
+	b.maintainer = ""
+	b.cmdSet = false
+	b.Config = &runconfig.Config{}
+	b.argsSet = map[string]string{}
+}
+
+// stageImage returns the image of the completed stage with the given name,
+// or of the image otherwise. With byIndex, stages can also be referred to by
+// their index in the Dockerfile, as with COPY --from=0.
+func (b *Builder) stageImage(name string, byIndex bool) (*imagepkg.Image, error) {
+	if name != "" && strings.ToLower(name) == b.stageName {
+		return nil, fmt.Errorf("The stage %s can't refer to itself", name)
+	}
+	if i, ok := b.stageNames[strings.ToLower(name)]; ok {
+		return b.Daemon.Graph().Get(b.stageImages[i])
+	}
+	if byIndex {
+		if i, err := strconv.Atoi(name); err == nil {
+			if i < 0 || i >= len(b.stageImages) {
+				return nil, fmt.Errorf("Invalid stage index %d, only the previous stages can be used", i)
+			}
+			return b.Daemon.Graph().Get(b.stageImages[i])
+		}
+	}
+
+	image, err := b.Daemon.Repositories().LookupImage(name)
+	if b.Pull {
+		image, err = b.pullImage(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		if b.Daemon.Graph().IsNotExist(err) {
+			image, err = b.pullImage(name)
+		}
+
+		// note that the top level err will still be !nil here if IsNotExist is
+		// not the error. This approach just simplifies hte logic a bit.
+		if err != nil {
+			return nil, err
+		}
+	}
+	return image, nil
+}
+
 func (b *Builder) pullImage(name string) (*imagepkg.Image, error) {
 	remote, tag := parsers.ParseRepositoryTag(name)
 	if tag == "" {
@@ -435,6 +645,7 @@ func (b *Builder) pullImage(name string) (*imagepkg.Image, error) {
 
 func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 	b.image = img.ID
+	b.fromImage = img.ID
 
 	if img.Config != nil {
 		b.Config = img.Config
@@ -453,10 +664,17 @@ func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 	onBuildTriggers := b.Config.OnBuild
 	b.Config.OnBuild = []string{}
 
+	b.inTrigger = true
+	defer func() { b.inTrigger = false }()
+
 	// parse the ONBUILD triggers by invoking the parser
 	for stepN, step := range onBuildTriggers {
 		ast, err := parser.Parse(strings.NewReader(step))
 		if err != nil {
+			// the lines of the trigger are not the ones of the Dockerfile
+			if lerr, ok := err.(*parser.LineError); ok {
+				err = lerr.Err
+			}
 			return err
 		}
 
@@ -468,7 +686,9 @@ func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 				return fmt.Errorf("%s isn't allowed as an ONBUILD trigger", n.Value)
 			}
 
-			fmt.Fprintf(b.OutStream, "Trigger %d, %s\n", stepN, step)
+			if !b.jsonSteps() {
+				fmt.Fprintf(b.OutStream, "Trigger %d, %s\n", stepN, step)
+			}
 
 			if err := b.dispatch(i, n); err != nil {
 				return err
@@ -479,6 +699,32 @@ func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 	return nil
 }
 
+// loadCacheFrom gathers the images given with --cache-from and their
+// parents, these are the only images used as the build cache then.
+func (b *Builder) loadCacheFrom() error {
+	if len(b.CacheFrom) == 0 {
+		return nil
+	}
+	b.cacheFrom = map[string]struct{}{}
+	for _, name := range b.CacheFrom {
+		img, err := b.Daemon.Repositories().LookupImage(name)
+		if err != nil {
+			if !b.Daemon.Graph().IsNotExist(err) {
+				return err
+			}
+			fmt.Fprintf(b.ErrStream, "# Cache image %s not found, it must be pulled or loaded first\n", name)
+			continue
+		}
+		for img != nil {
+			b.cacheFrom[img.ID] = struct{}{}
+			if img, err = img.GetParent(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // probeCache checks to see if image-caching is enabled (`b.UtilizeCache`)
 // and if so attempts to look up the current `b.image` and `b.Config` pair
 // in the current server `b.Daemon`. If an image is found, probeCache returns
@@ -486,10 +732,11 @@ func (b *Builder) processImageFrom(img *imagepkg.Image) error {
 // is any error, it returns `(false, err)`.
 func (b *Builder) probeCache() (bool, error) {
 	if b.UtilizeCache {
-		if cache, err := b.Daemon.ImageGetCached(b.image, b.Config); err != nil {
+		if cache, err := b.Daemon.ImageGetCached(b.image, b.Config, b.cacheFrom); err != nil {
 			return false, err
 		} else if cache != nil {
-			fmt.Fprintf(b.OutStream, " ---> Using cache\n")
+			b.step.Cached = true
+			b.emitStep(&utils.JSONStep{Index: b.step.Index, Event: utils.StepCache})
 			log.Debugf("[BUILDER] Use cached version")
 			b.image = cache.ID
 			return true, nil
@@ -518,7 +765,8 @@ func (b *Builder) create() (*daemon.Container, error) {
 	}
 
 	b.TmpContainers[c.ID] = struct{}{}
-	fmt.Fprintf(b.OutStream, " ---> Running in %s\n", utils.TruncateID(c.ID))
+	b.step.ContainerID = c.ID
+	b.emitStep(&utils.JSONStep{Index: b.step.Index, Event: utils.StepContainer, ContainerID: c.ID})
 
 	// override the entry point that may have been picked up from the base image
 	c.Path = config.Cmd[0]
@@ -538,9 +786,14 @@ func (b *Builder) run(c *daemon.Container) error {
 		logsJob.Setenv("follow", "1")
 		logsJob.Setenv("stdout", "1")
 		logsJob.Setenv("stderr", "1")
-		logsJob.Stdout.Add(b.OutStream)
-		logsJob.Stderr.Set(b.ErrStream)
-		if err := logsJob.Run(); err != nil {
+		stdout := &stepOutput{b: b}
+		stderr := &stepOutput{b: b, stderr: true}
+		logsJob.Stdout.Add(stdout)
+		logsJob.Stderr.Set(stderr)
+		err := logsJob.Run()
+		stdout.flush()
+		stderr.flush()
+		if err != nil {
 			return err
 		}
 	}
@@ -578,11 +831,11 @@ func (b *Builder) checkPathForAddition(orig string) error {
 	return nil
 }
 
-func (b *Builder) addContext(container *daemon.Container, orig, dest string, decompress bool) error {
+func (b *Builder) addContext(container *daemon.Container, root, orig, dest string, decompress bool) error {
 	var (
 		err        error
 		destExists = true
-		origPath   = path.Join(b.contextPath, orig)
+		origPath   = path.Join(root, orig)
 		destPath   = path.Join(container.RootfsPath(), dest)
 	)
 
@@ -614,8 +867,12 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		return err
 	}
 
+	// Files are owned by root in the container, which the user namespace
+	// remapping of the daemon may place on another uid and gid of the host
+	rootUID, rootGID := b.Daemon.GetRemappedUIDGID()
+
 	if fi.IsDir() {
-		return copyAsDirectory(origPath, destPath, destExists)
+		return copyAsDirectory(origPath, destPath, rootUID, rootGID, destExists)
 	}
 
 	// If we are adding a remote file (or we've been told not to decompress), do not try to untar it
@@ -630,7 +887,7 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		}
 
 		// try to successfully untar the orig
-		if err := chrootarchive.UntarPath(origPath, tarDest); err == nil {
+		if err := untarPath(origPath, tarDest, b.Daemon); err == nil {
 			return nil
 		} else if err != io.EOF {
 			log.Debugf("Couldn't untar %s to %s: %s", origPath, tarDest, err)
@@ -649,14 +906,29 @@ func (b *Builder) addContext(container *daemon.Container, orig, dest string, dec
 		resPath = path.Join(destPath, path.Base(origPath))
 	}
 
-	return fixPermissions(origPath, resPath, 0, 0, destExists)
+	return fixPermissions(origPath, resPath, rootUID, rootGID, destExists)
+}
+
+// untarPath unpacks the archive at src into dst, placing the ids of its
+// entries onto the host ids of the user namespace remapping of the daemon
+func untarPath(src, dst string, d *daemon.Daemon) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	uidMaps, gidMaps := d.GetUIDGIDMaps()
+	return chrootarchive.Untar(f, dst, &archive.TarOptions{
+		UIDMaps: uidMaps,
+		GIDMaps: gidMaps,
+	})
 }
 
-func copyAsDirectory(source, destination string, destExisted bool) error {
+func copyAsDirectory(source, destination string, uid, gid int, destExisted bool) error {
 	if err := chrootarchive.CopyWithTar(source, destination); err != nil {
 		return err
 	}
-	return fixPermissions(source, destination, 0, 0, destExisted)
+	return fixPermissions(source, destination, uid, gid, destExisted)
 }
 
 func fixPermissions(source, destination string, uid, gid int, destExisted bool) error {
@@ -691,6 +963,63 @@ func fixPermissions(source, destination string, uid, gid int, destExisted bool)
 	})
 }
 
+// jsonSteps returns true if the steps are sent as JSON events.
+func (b *Builder) jsonSteps() bool {
+	return b.Steps && b.StreamFormatter.Json()
+}
+
+// emitStep sends an event of the current step, or its text when the client
+// doesn't ask for the events.
+func (b *Builder) emitStep(step *utils.JSONStep) {
+	if b.jsonSteps() {
+		b.OutOld.Write(b.StreamFormatter.FormatStep(step))
+		return
+	}
+	if step.Event == utils.StepOutput && step.Stderr {
+		b.ErrStream.Write([]byte(step.Output))
+		return
+	}
+	fmt.Fprint(b.OutStream, step.String())
+}
+
+// stepOutput sends the output of the intermediate container of the current
+// step as step events, one for each line. The last line is sent by flush
+// if it doesn't end with a newline.
+type stepOutput struct {
+	b      *Builder
+	stderr bool
+	buf    []byte
+}
+
+func (w *stepOutput) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		w.emit(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *stepOutput) flush() {
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *stepOutput) emit(line []byte) {
+	w.b.emitStep(&utils.JSONStep{
+		Index:  w.b.step.Index,
+		Event:  utils.StepOutput,
+		Output: string(line),
+		Stderr: w.stderr,
+	})
+}
+
 func (b *Builder) clearTmp() {
 	for c := range b.TmpContainers {
 		tmp := b.Daemon.Get(c)
@@ -0,0 +1,49 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/docker/docker/utils"
+)
+
+func TestStepOutputLines(t *testing.T) {
+	out := &bytes.Buffer{}
+	b := &Builder{
+		Steps:           true,
+		StreamFormatter: utils.NewStreamFormatter(true),
+		OutOld:          out,
+		step:            &utils.JSONStep{Index: 2},
+	}
+	w := &stepOutput{b: b, stderr: true}
+	for _, chunk := range []string{"he", "llo\nwor", "ld\n\nno newline"} {
+		w.Write([]byte(chunk))
+	}
+	w.flush()
+
+	var lines []string
+	dec := json.NewDecoder(out)
+	for {
+		var jm utils.JSONMessage
+		if err := dec.Decode(&jm); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if jm.Step.Index != 2 || jm.Step.Event != utils.StepOutput || !jm.Step.Stderr {
+			t.Fatalf("Expected an output event of the step 2 on stderr, got %+v", jm.Step)
+		}
+		lines = append(lines, jm.Step.Output)
+	}
+	expected := []string{"hello\n", "world\n", "\n", "no newline"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected the events %q, got %q", expected, lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("Expected the events %q, got %q", expected, lines)
+		}
+	}
+}
@@ -37,13 +37,26 @@ func (b *BuilderJob) CmdBuild(job *engine.Job) engine.Status {
 		rm             = job.GetenvBool("rm")
 		forceRm        = job.GetenvBool("forcerm")
 		pull           = job.GetenvBool("pull")
+		dockerfileName = job.Getenv("dockerfile")
+		target         = job.Getenv("target")
+		steps          = job.GetenvBool("steps")
+		squash         = job.GetenvBool("squash")
+		check          = job.GetenvBool("check")
 		authConfig     = &registry.AuthConfig{}
 		configFile     = &registry.ConfigFile{}
+		buildArgs      = map[string]string{}
+		cacheFrom      = []string{}
 		tag            string
 		context        io.ReadCloser
 	)
 	job.GetenvJson("authConfig", authConfig)
 	job.GetenvJson("configFile", configFile)
+	if err := job.GetenvJson("buildargs", &buildArgs); err != nil {
+		return job.Errorf("Invalid build-args: %s", err)
+	}
+	if err := job.GetenvJson("cachefrom", &cacheFrom); err != nil {
+		return job.Errorf("Invalid cache-from: %s", err)
+	}
 
 	repoName, tag = parsers.ParseRepositoryTag(repoName)
 	if repoName != "" {
@@ -88,7 +101,11 @@ func (b *BuilderJob) CmdBuild(job *engine.Job) engine.Status {
 		if err != nil {
 			return job.Error(err)
 		}
-		c, err := archive.Generate("Dockerfile", string(dockerFile))
+		name := dockerfileName
+		if name == "" {
+			name = "Dockerfile"
+		}
+		c, err := archive.Generate(name, string(dockerFile))
 		if err != nil {
 			return job.Error(err)
 		}
@@ -118,6 +135,19 @@ func (b *BuilderJob) CmdBuild(job *engine.Job) engine.Status {
 		StreamFormatter: sf,
 		AuthConfig:      authConfig,
 		AuthConfigFile:  configFile,
+		BuildArgs:       buildArgs,
+		DockerfileName:  dockerfileName,
+		Target:          target,
+		CacheFrom:       cacheFrom,
+		Steps:           steps,
+		Squash:          squash,
+	}
+
+	if check {
+		if err := builder.Check(context); err != nil {
+			return job.Error(err)
+		}
+		return engine.StatusOK
 	}
 
 	id, err := builder.Run(context)
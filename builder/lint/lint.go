@@ -0,0 +1,200 @@
+// Package lint reports the mistakes of a Dockerfile from its parse tree,
+// without building it.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/builder/parser"
+)
+
+// Issue is a mistake found in a Dockerfile.
+type Issue struct {
+	Line        int    // line of the Dockerfile where the instruction starts
+	Instruction string // the instruction, in upper case
+	Message     string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+var (
+	// an argument that looks like a JSON array, rather than a shell command
+	// such as `[ -f file ]`
+	tokenJSONArray     = regexp.MustCompile(`^\[\s*['"]`)
+	tokenAptGetUpdate  = regexp.MustCompile(`\bapt-get\s+(-\S+\s+)*update\b`)
+	tokenAptGetInstall = regexp.MustCompile(`\bapt-get\s+(-\S+\s+)*install\b`)
+)
+
+// the variables set in the environment of every container
+var defaultEnv = []string{"PATH", "HOME", "HOSTNAME"}
+
+// the sources that ADD extracts rather than copies
+var archiveExtensions = []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tbz", ".tar.xz", ".txz", ".gz", ".bz2", ".xz"}
+
+type checker struct {
+	issues []Issue
+	line   int
+	stages map[string]struct{} // the names of the previous stages
+	vars   map[string]struct{} // the variables set by ENV and ARG in the current stage
+}
+
+// Check walks the parse tree of a Dockerfile, as returned by parser.Parse,
+// and returns the issues found in order.
+func Check(ast *parser.Node) []Issue {
+	c := &checker{
+		stages: map[string]struct{}{},
+		vars:   map[string]struct{}{},
+	}
+	for _, n := range ast.Children {
+		c.line = n.StartLine
+		if n.Value == "onbuild" && n.Next != nil && len(n.Next.Children) > 0 {
+			// the variables and the image of the trigger are known when it
+			// runs in another build
+			c.checkInstruction(n.Next.Children[0])
+			continue
+		}
+		c.checkInstruction(n)
+		c.checkVars(n)
+		c.declare(n)
+	}
+	return c.issues
+}
+
+func (c *checker) report(node *parser.Node, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{
+		Line:        c.line,
+		Instruction: strings.ToUpper(node.Value),
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) checkInstruction(node *parser.Node) {
+	if !parser.IsInstruction(node.Value) {
+		c.report(node, "Unknown instruction %s", strings.ToUpper(node.Value))
+		return
+	}
+
+	args := []string{}
+	for n := node.Next; n != nil; n = n.Next {
+		args = append(args, n.Value)
+	}
+
+	switch node.Value {
+	case "from":
+		c.checkFrom(node, args)
+	case "run", "cmd", "entrypoint", "shell", "volume":
+		if len(args) > 0 && !node.Attributes["json"] && tokenJSONArray.MatchString(args[0]) {
+			c.report(node, "Invalid JSON array in %s, the arguments are not used as a list: %s", strings.ToUpper(node.Value), strings.Join(args, " "))
+		}
+		if node.Value == "run" {
+			c.checkAptGet(node, strings.Join(args, " "))
+		}
+	case "healthcheck":
+		for i, arg := range args {
+			if strings.ToUpper(arg) == "CMD" && i+1 < len(args) && !node.Attributes["json"] && tokenJSONArray.MatchString(args[i+1]) {
+				c.report(node, "Invalid JSON array in HEALTHCHECK CMD, the arguments are not used as a list: %s", strings.Join(args[i+1:], " "))
+			}
+		}
+	case "add":
+		c.checkAdd(node, args)
+	}
+}
+
+func (c *checker) checkFrom(node *parser.Node, args []string) {
+	if len(args) == 0 {
+		return
+	}
+	image := args[0]
+	if _, ok := c.stages[strings.ToLower(image)]; !ok && image != "scratch" && !strings.Contains(image, "@") {
+		name := image
+		if i := strings.LastIndex(name, "/"); i != -1 {
+			name = name[i+1:]
+		}
+		if !strings.Contains(name, ":") {
+			c.report(node, "FROM %s has no tag, the image tagged latest is used", image)
+		}
+	}
+}
+
+func (c *checker) checkAptGet(node *parser.Node, command string) {
+	if tokenAptGetUpdate.MatchString(command) && !tokenAptGetInstall.MatchString(command) {
+		c.report(node, "apt-get update without apt-get install in the same RUN, a cached update leaves the package lists of a later install out of date")
+	}
+}
+
+func (c *checker) checkAdd(node *parser.Node, args []string) {
+	srcs := []string{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			srcs = append(srcs, arg)
+		}
+	}
+	if len(srcs) < 2 {
+		return
+	}
+	for _, src := range srcs[:len(srcs)-1] {
+		if strings.Contains(src, "://") || strings.ContainsAny(src, "*?[") {
+			return
+		}
+		for _, ext := range archiveExtensions {
+			if strings.HasSuffix(strings.ToLower(src), ext) {
+				return
+			}
+		}
+	}
+	c.report(node, "ADD copies local files which are not archives, use COPY instead")
+}
+
+// checkVars reports the variables used by the instruction which are not set
+// by ENV or ARG before it, in the same stage. The environment of the FROM
+// image is not known, only the variables of every container are.
+func (c *checker) checkVars(node *parser.Node) {
+	if _, ok := parser.ReplaceEnvAllowed[node.Value]; !ok {
+		return
+	}
+	reported := map[string]struct{}{}
+	for n := node.Next; n != nil; n = n.Next {
+		for _, match := range parser.TOKEN_ENV_INTERPOLATION.FindAllString(n.Value, -1) {
+			if strings.Contains(match, "\\$") {
+				continue
+			}
+			name := strings.Trim(match[strings.Index(match, "$"):], "${}")
+			if _, ok := c.vars[name]; ok {
+				continue
+			}
+			if _, ok := reported[name]; ok {
+				continue
+			}
+			reported[name] = struct{}{}
+			c.report(node, "$%s is not set by ENV or ARG before %s", name, strings.ToUpper(node.Value))
+		}
+	}
+}
+
+// declare records the stages and the variables set by the instruction.
+func (c *checker) declare(node *parser.Node) {
+	switch node.Value {
+	case "from":
+		c.vars = map[string]struct{}{}
+		for _, name := range defaultEnv {
+			c.vars[name] = struct{}{}
+		}
+		// FROM image AS name
+		if n := node.Next; n != nil && n.Next != nil && n.Next.Next != nil {
+			c.stages[strings.ToLower(n.Next.Next.Value)] = struct{}{}
+		}
+	case "arg":
+		if node.Next != nil {
+			c.vars[node.Next.Value] = struct{}{}
+		}
+	case "env":
+		// ENV name value name value...
+		for n := node.Next; n != nil && n.Next != nil; n = n.Next.Next {
+			c.vars[n.Value] = struct{}{}
+		}
+	}
+}
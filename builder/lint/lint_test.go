@@ -0,0 +1,62 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/builder/parser"
+)
+
+func check(t *testing.T, dockerfile string) []Issue {
+	ast, err := parser.Parse(strings.NewReader(dockerfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Check(ast)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		dockerfile string
+		line       int
+		message    string
+	}{
+		{"FROM busybox:latest\nCOPYY . /app", 2, "Unknown instruction COPYY"},
+		{"FROM busybox:latest\nCMD ['echo', 'hi']", 2, "Invalid JSON array in CMD"},
+		{"FROM busybox:latest\nVOLUME [\"/data\"", 2, "Invalid JSON array in VOLUME"},
+		{"FROM busybox:latest\nHEALTHCHECK --retries=2 CMD [\"true\",]", 2, "Invalid JSON array in HEALTHCHECK CMD"},
+		{"FROM busybox:latest\n\nADD app.conf /etc/", 3, "ADD copies local files"},
+		{"FROM debian:wheezy\nRUN apt-get -q update", 2, "apt-get update without apt-get install"},
+		{"FROM busybox", 1, "FROM busybox has no tag"},
+		{"FROM localhost:5000/app", 1, "FROM localhost:5000/app has no tag"},
+		{"FROM busybox:latest\nRUN true \\\n  && true\nWORKDIR $APP", 4, "$APP is not set by ENV or ARG before WORKDIR"},
+		{"FROM busybox:latest\nENV A=1 B=${A}", 2, "$A is not set"},
+		{"FROM busybox:latest\nARG APP\nFROM busybox:latest\nWORKDIR ${APP}", 4, "$APP is not set"},
+	}
+	for _, test := range tests {
+		issues := check(t, test.dockerfile)
+		if len(issues) != 1 {
+			t.Fatalf("expected 1 issue in %q, got %v", test.dockerfile, issues)
+		}
+		if issues[0].Line != test.line || !strings.Contains(issues[0].Message, test.message) {
+			t.Fatalf("expected %q at line %d in %q, got %s", test.message, test.line, test.dockerfile, issues[0])
+		}
+	}
+}
+
+func TestCheckNoIssues(t *testing.T) {
+	dockerfiles := []string{
+		"FROM scratch\nADD rootfs.tar.xz /\nADD http://example.com/app /app",
+		"FROM busybox:latest\nRUN [ -f /etc/passwd ] && echo ok\nCMD [\"echo\", \"hi\"]",
+		"FROM debian:wheezy\nRUN apt-get update && apt-get install -y curl",
+		"FROM golang:1.3 AS build\nFROM build\nFROM registry.example.com:5000/app@sha256:abc",
+		"FROM busybox:latest\nARG DIR=/app\nENV HOME_DIR=$HOME\nENV APP $DIR\nWORKDIR ${APP}/bin\nRUN echo $UNKNOWN\nWORKDIR /\\$UNKNOWN",
+		"FROM busybox:latest\nONBUILD WORKDIR $APP\nONBUILD COPY . /app",
+		"FROM busybox:latest\nCOPY --from=0 /app /app\nEXPOSE 80",
+	}
+	for _, dockerfile := range dockerfiles {
+		if issues := check(t, dockerfile); len(issues) != 0 {
+			t.Fatalf("expected no issue in %q, got %v", dockerfile, issues)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -11,12 +12,15 @@ func main() {
 	var f *os.File
 	var err error
 
-	if len(os.Args) < 2 {
+	lines := flag.Bool("lines", false, "print the lines of each instruction, as start-end")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
 		fmt.Println("please supply filename(s)")
 		os.Exit(1)
 	}
 
-	for _, fn := range os.Args[1:] {
+	for _, fn := range flag.Args() {
 		f, err = os.Open(fn)
 		if err != nil {
 			panic(err)
@@ -25,6 +29,10 @@ func main() {
 		ast, err := parser.Parse(f)
 		if err != nil {
 			panic(err)
+		} else if *lines {
+			for _, n := range ast.Children {
+				fmt.Printf("%d-%d (%s)\n", n.StartLine, n.EndLine, n.Dump())
+			}
 		} else {
 			fmt.Println(ast.Dump())
 		}
@@ -41,10 +41,10 @@ func parseSubCommand(rest string) (*Node, map[string]bool, error) {
 
 // parse environment like statements. Note that this does *not* handle
 // variable interpolation, which will be handled in the evaluator.
-func parseEnv(rest string) (*Node, map[string]bool, error) {
+func parseNameVal(rest string, key string) (*Node, map[string]bool, error) {
 	// This is kind of tricky because we need to support the old
-	// variant:   ENV name value
-	// as well as the new one:    ENV name=value ...
+	// variant:   KEY name value
+	// as well as the new one:    KEY name=value ...
 	// The trigger to know which one is being used will be whether we hit
 	// a space or = first.  space ==> old, "=" ==> new
 
@@ -134,10 +134,10 @@ func parseEnv(rest string) (*Node, map[string]bool, error) {
 	}
 
 	if len(words) == 0 {
-		return nil, nil, fmt.Errorf("ENV must have some arguments")
+		return nil, nil, fmt.Errorf("%s must have some arguments", key)
 	}
 
-	// Old format (ENV name value)
+	// Old format (KEY name value)
 	var rootnode *Node
 
 	if !strings.Contains(words[0], "=") {
@@ -146,7 +146,7 @@ func parseEnv(rest string) (*Node, map[string]bool, error) {
 		strs := TOKEN_WHITESPACE.Split(rest, 2)
 
 		if len(strs) < 2 {
-			return nil, nil, fmt.Errorf("ENV must have two arguments")
+			return nil, nil, fmt.Errorf("%s must have two arguments", key)
 		}
 
 		node.Value = strs[0]
@@ -179,6 +179,84 @@ func parseEnv(rest string) (*Node, map[string]bool, error) {
 	return rootnode, nil, nil
 }
 
+func parseEnv(rest string) (*Node, map[string]bool, error) {
+	return parseNameVal(rest, "ENV")
+}
+
+func parseLabel(rest string) (*Node, map[string]bool, error) {
+	return parseNameVal(rest, "LABEL")
+}
+
+// parses ARG name[=default]. The default is quoted like the values of ENV.
+//
+// ARG foo=bar -> (arg "foo" "bar")
+//
+func parseArg(rest string) (*Node, map[string]bool, error) {
+	strs := TOKEN_WHITESPACE.Split(strings.TrimSpace(rest), 2)
+	if strs[0] == "" {
+		return nil, nil, fmt.Errorf("ARG requires exactly one argument")
+	}
+
+	if !strings.Contains(strs[0], "=") {
+		if len(strs) > 1 {
+			return nil, nil, fmt.Errorf("ARG requires exactly one argument")
+		}
+		return &Node{Value: strs[0]}, nil, nil
+	}
+
+	node, _, err := parseNameVal(rest, "ARG")
+	if err != nil {
+		return nil, nil, err
+	}
+	if node.Value == "" {
+		return nil, nil, fmt.Errorf("ARG requires a name")
+	}
+	if node.Next.Next != nil {
+		return nil, nil, fmt.Errorf("ARG requires exactly one argument")
+	}
+	return node, nil, nil
+}
+
+// parses HEALTHCHECK [--option=value...] TYPE [args...]. The arguments of
+// CMD are parsed like the ones of RUN, the other types take
+// whitespace-delimited arguments.
+//
+// HEALTHCHECK --interval=5s CMD curl -f http://localhost/ ->
+// (healthcheck "--interval=5s" "CMD" "curl -f http://localhost/")
+//
+func parseHealthcheck(rest string) (*Node, map[string]bool, error) {
+	rootnode := &Node{}
+	node := rootnode
+	rest = strings.TrimSpace(rest)
+	for rest != "" {
+		words := TOKEN_WHITESPACE.Split(rest, 2)
+		node.Next = &Node{Value: words[0]}
+		node = node.Next
+		rest = ""
+		if len(words) == 2 {
+			rest = strings.TrimSpace(words[1])
+		}
+		if strings.HasPrefix(words[0], "--") {
+			continue
+		}
+
+		if rest == "" {
+			break
+		}
+		parse := parseStringsWhitespaceDelimited
+		if strings.ToUpper(words[0]) == "CMD" {
+			parse = parseMaybeJSON
+		}
+		args, attrs, err := parse(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node.Next = args
+		return rootnode.Next, attrs, nil
+	}
+	return rootnode.Next, nil, nil
+}
+
 // parses a whitespace-delimited set of arguments. The result is effectively a
 // linked list of string arguments.
 func parseStringsWhitespaceDelimited(rest string) (*Node, map[string]bool, error) {
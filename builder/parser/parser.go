@@ -3,6 +3,7 @@ package parser
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"regexp"
 	"strings"
@@ -27,6 +28,22 @@ type Node struct {
 	Children   []*Node         // the children of this sexp
 	Attributes map[string]bool // special attributes for this node
 	Original   string          // original line used before parsing
+	StartLine  int             // first line of the instruction in the Dockerfile
+	EndLine    int             // last line of the instruction, after the continuation lines
+}
+
+// LineError is an error on the lines StartLine to EndLine of a Dockerfile.
+type LineError struct {
+	StartLine int
+	EndLine   int
+	Err       error
+}
+
+func (e *LineError) Error() string {
+	if e.StartLine == e.EndLine {
+		return fmt.Sprintf("Dockerfile line %d: %v", e.StartLine, e.Err)
+	}
+	return fmt.Sprintf("Dockerfile lines %d-%d: %v", e.StartLine, e.EndLine, e.Err)
 }
 
 var (
@@ -34,8 +51,28 @@ var (
 	TOKEN_WHITESPACE        = regexp.MustCompile(`[\t\v\f\r ]+`)
 	TOKEN_LINE_CONTINUATION = regexp.MustCompile(`\\\s*$`)
 	TOKEN_COMMENT           = regexp.MustCompile(`^#.*$`)
+	// `\\\\+|[^\\]|\b|\A` - match any number of "\\" (ie, properly-escaped backslashes), or a single non-backslash character, or a word boundary, or beginning-of-line
+	// `\$` - match literal $
+	// `[[:alnum:]_]+` - match things like `$SOME_VAR`
+	// `{[[:alnum:]_]+}` - match things like `${SOME_VAR}`
+	TOKEN_ENV_INTERPOLATION = regexp.MustCompile(`(\\|\\\\+|[^\\]|\b|\A)\$([[:alnum:]_]+|{[[:alnum:]_]+})`)
+	// this intentionally punts on more exotic interpolations like ${SOME_VAR%suffix} and lets the shell handle those directly
 )
 
+// Environment variable interpolation will happen on these statements only.
+var ReplaceEnvAllowed = map[string]struct{}{
+	"env":        {},
+	"label":      {},
+	"arg":        {},
+	"add":        {},
+	"copy":       {},
+	"workdir":    {},
+	"expose":     {},
+	"volume":     {},
+	"user":       {},
+	"stopsignal": {},
+}
+
 func init() {
 	// Dispatch Table. see line_parsers.go for the parse functions.
 	// The command is parsed and mapped to the line parser. The line parser
@@ -44,23 +81,35 @@ func init() {
 	// functions. Errors are propogated up by Parse() and the resulting AST can
 	// be incorporated directly into the existing AST as a next.
 	dispatch = map[string]func(string) (*Node, map[string]bool, error){
-		"user":       parseString,
-		"onbuild":    parseSubCommand,
-		"workdir":    parseString,
-		"env":        parseEnv,
-		"maintainer": parseString,
-		"from":       parseString,
-		"add":        parseStringsWhitespaceDelimited,
-		"copy":       parseStringsWhitespaceDelimited,
-		"run":        parseMaybeJSON,
-		"cmd":        parseMaybeJSON,
-		"entrypoint": parseMaybeJSON,
-		"expose":     parseStringsWhitespaceDelimited,
-		"volume":     parseMaybeJSONToList,
-		"insert":     parseIgnore,
+		"user":        parseString,
+		"onbuild":     parseSubCommand,
+		"workdir":     parseString,
+		"env":         parseEnv,
+		"label":       parseLabel,
+		"arg":         parseArg,
+		"maintainer":  parseString,
+		"from":        parseStringsWhitespaceDelimited,
+		"add":         parseStringsWhitespaceDelimited,
+		"copy":        parseStringsWhitespaceDelimited,
+		"run":         parseMaybeJSON,
+		"cmd":         parseMaybeJSON,
+		"entrypoint":  parseMaybeJSON,
+		"expose":      parseStringsWhitespaceDelimited,
+		"volume":      parseMaybeJSONToList,
+		"stopsignal":  parseString,
+		"shell":       parseMaybeJSON,
+		"healthcheck": parseHealthcheck,
+		"insert":      parseIgnore,
 	}
 }
 
+// IsInstruction returns true if cmd, in lower case, is a Dockerfile
+// instruction. The parser ignores the arguments of the other ones.
+func IsInstruction(cmd string) bool {
+	_, ok := dispatch[cmd]
+	return ok
+}
+
 // parse a line and return the remainder.
 func parseLine(line string) (string, *Node, error) {
 	if line = stripComments(line); line == "" {
@@ -95,30 +144,47 @@ func parseLine(line string) (string, *Node, error) {
 	return "", node, nil
 }
 
+// setLines sets the lines of the node and of all the nodes under it.
+func (node *Node) setLines(start, end int) {
+	for n := node; n != nil; n = n.Next {
+		n.StartLine = start
+		n.EndLine = end
+		for _, child := range n.Children {
+			child.setLines(start, end)
+		}
+	}
+}
+
 // The main parse routine. Handles an io.ReadWriteCloser and returns the root
-// of the AST.
+// of the AST. The root spans the whole Dockerfile, and its children the lines
+// of their instruction. The errors are *LineError.
 func Parse(rwc io.Reader) (*Node, error) {
 	root := &Node{}
 	scanner := bufio.NewScanner(rwc)
+	lineno := 0
 
 	for scanner.Scan() {
+		lineno++
+		startLine, endLine := lineno, lineno
 		scannedLine := strings.TrimLeftFunc(scanner.Text(), unicode.IsSpace)
 		line, child, err := parseLine(scannedLine)
 		if err != nil {
-			return nil, err
+			return nil, &LineError{StartLine: startLine, EndLine: endLine, Err: err}
 		}
 
 		if line != "" && child == nil {
 			for scanner.Scan() {
+				lineno++
 				newline := scanner.Text()
 
 				if stripComments(strings.TrimSpace(newline)) == "" {
 					continue
 				}
+				endLine = lineno
 
 				line, child, err = parseLine(line + newline)
 				if err != nil {
-					return nil, err
+					return nil, &LineError{StartLine: startLine, EndLine: endLine, Err: err}
 				}
 
 				if child != nil {
@@ -128,15 +194,19 @@ func Parse(rwc io.Reader) (*Node, error) {
 			if child == nil && line != "" {
 				line, child, err = parseLine(line)
 				if err != nil {
-					return nil, err
+					return nil, &LineError{StartLine: startLine, EndLine: endLine, Err: err}
 				}
 			}
 		}
 
 		if child != nil {
+			child.setLines(startLine, endLine)
 			root.Children = append(root.Children, child)
 		}
 	}
+	if lineno > 0 {
+		root.StartLine, root.EndLine = 1, lineno
+	}
 
 	return root, nil
 }
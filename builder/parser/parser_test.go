@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -80,3 +81,58 @@ func TestTestData(t *testing.T) {
 		rf.Close()
 	}
 }
+
+func TestParseLines(t *testing.T) {
+	df, err := os.Open(filepath.Join(testDir, "continueIndent", "Dockerfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer df.Close()
+
+	ast, err := Parse(df)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ast.StartLine != 1 || ast.EndLine != 36 {
+		t.Fatalf("expected the root on lines 1-36, got %d-%d", ast.StartLine, ast.EndLine)
+	}
+
+	expected := [][2]int{{1, 1}, {3, 8}, {9, 10}, {11, 12}, {13, 15}, {16, 17}, {18, 24}, {25, 26}, {27, 29}, {31, 36}}
+	if len(ast.Children) != len(expected) {
+		t.Fatalf("expected %d instructions, got %d", len(expected), len(ast.Children))
+	}
+	for i, child := range ast.Children {
+		for n := child; n != nil; n = n.Next {
+			if n.StartLine != expected[i][0] || n.EndLine != expected[i][1] {
+				t.Fatalf("expected %q on lines %d-%d, got %d-%d", n.Value, expected[i][0], expected[i][1], n.StartLine, n.EndLine)
+			}
+		}
+	}
+}
+
+func TestParseLinesOnbuild(t *testing.T) {
+	ast, err := Parse(strings.NewReader("FROM busybox\n\nONBUILD RUN echo \\\n  hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trigger := ast.Children[1].Next.Children[0]
+	if trigger.StartLine != 3 || trigger.EndLine != 4 || trigger.Next.StartLine != 3 || trigger.Next.EndLine != 4 {
+		t.Fatalf("expected the ONBUILD trigger on lines 3-4, got %d-%d", trigger.StartLine, trigger.EndLine)
+	}
+}
+
+func TestParseErrorLines(t *testing.T) {
+	for dockerfile, lines := range map[string][2]int{
+		"FROM busybox\nENV FOO":                         {2, 2},
+		"FROM busybox\n\nCMD [\"echo\", \\\n  [\"x\"]]": {3, 4},
+	} {
+		_, err := Parse(strings.NewReader(dockerfile))
+		lerr, ok := err.(*LineError)
+		if !ok {
+			t.Fatalf("expected a *LineError for %q, got %v", dockerfile, err)
+		}
+		if lerr.StartLine != lines[0] || lerr.EndLine != lines[1] {
+			t.Fatalf("expected the error of %q on lines %d-%d, got %s", dockerfile, lines[0], lines[1], lerr)
+		}
+	}
+}
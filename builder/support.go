@@ -1,22 +1,15 @@
 package builder
 
 import (
-	"regexp"
+	"sort"
 	"strings"
-)
 
-var (
-	// `\\\\+|[^\\]|\b|\A` - match any number of "\\" (ie, properly-escaped backslashes), or a single non-backslash character, or a word boundary, or beginning-of-line
-	// `\$` - match literal $
-	// `[[:alnum:]_]+` - match things like `$SOME_VAR`
-	// `{[[:alnum:]_]+}` - match things like `${SOME_VAR}`
-	tokenEnvInterpolation = regexp.MustCompile(`(\\|\\\\+|[^\\]|\b|\A)\$([[:alnum:]_]+|{[[:alnum:]_]+})`)
-	// this intentionally punts on more exotic interpolations like ${SOME_VAR%suffix} and lets the shell handle those directly
+	"github.com/docker/docker/builder/parser"
 )
 
 // handle environment replacement. Used in dispatcher.
 func (b *Builder) replaceEnv(str string) string {
-	for _, match := range tokenEnvInterpolation.FindAllString(str, -1) {
+	for _, match := range parser.TOKEN_ENV_INTERPOLATION.FindAllString(str, -1) {
 		idx := strings.Index(match, "\\$")
 		if idx != -1 {
 			if idx+2 >= len(match) {
@@ -33,18 +26,44 @@ func (b *Builder) replaceEnv(str string) string {
 		match = match[strings.Index(match, "$"):]
 		matchKey := strings.Trim(match, "${}")
 
+		found := false
 		for _, keyval := range b.Config.Env {
 			tmp := strings.SplitN(keyval, "=", 2)
 			if tmp[0] == matchKey {
 				str = strings.Replace(str, match, tmp[1], -1)
+				found = true
 				break
 			}
 		}
+		// ENV takes precedence over ARG
+		if value, ok := b.argsSet[matchKey]; ok && !found {
+			str = strings.Replace(str, match, value, -1)
+		}
 	}
 
 	return str
 }
 
+// buildArgsEnv returns the build args which are not overridden by ENV, as
+// sorted environment variables
+func (b *Builder) buildArgsEnv() []string {
+	env := []string{}
+	for name, value := range b.argsSet {
+		overridden := false
+		for _, keyval := range b.Config.Env {
+			if strings.SplitN(keyval, "=", 2)[0] == name {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			env = append(env, name+"="+value)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
 func handleJsonArgs(args []string, attributes map[string]bool) []string {
 	if len(args) == 0 {
 		return []string{}
@@ -57,3 +76,13 @@ func handleJsonArgs(args []string, attributes map[string]bool) []string {
 	// literal string command, not an exec array
 	return []string{strings.Join(args, " ")}
 }
+
+// shellForm returns the command of the shell form of RUN, CMD and
+// ENTRYPOINT, run by the shell set with SHELL or by /bin/sh -c.
+func (b *Builder) shellForm(command ...string) []string {
+	shell := []string{"/bin/sh", "-c"}
+	if len(b.Config.Shell) > 0 {
+		shell = append([]string{}, b.Config.Shell...)
+	}
+	return append(shell, command...)
+}
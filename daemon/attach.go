@@ -80,6 +80,8 @@ func (daemon *Daemon) ContainerAttach(job *engine.Job) engine.Status {
 
 	//stream
 	if stream {
+		container.LogEvent("attach")
+
 		var (
 			cStdin           io.ReadCloser
 			cStdout, cStderr io.Writer
@@ -34,6 +34,7 @@ func (daemon *Daemon) ContainerCommit(job *engine.Job) engine.Status {
 	if err != nil {
 		return job.Error(err)
 	}
+	container.LogEventAttributes("commit", map[string]string{"imageID": img.ID})
 	job.Printf("%s\n", img.ID)
 	return engine.StatusOK
 }
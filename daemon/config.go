@@ -2,10 +2,12 @@ package daemon
 
 import (
 	"net"
+	"time"
 
 	"github.com/docker/docker/daemon/networkdriver"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/ulimit"
 )
 
 const (
@@ -36,12 +38,20 @@ type Config struct {
 	GraphDriver                 string
 	GraphOptions                []string
 	ExecDriver                  string
+	LiveRestore                 bool
 	Mtu                         int
 	DisableNetwork              bool
 	EnableSelinuxSupport        bool
 	Context                     map[string][]string
 	TrustKeyPath                string
 	Labels                      []string
+	EventWebhooks               []string
+	EventWebhookBatchSize       int
+	EventWebhookQueueSize       int
+	EventWebhookFlushInterval   time.Duration
+	Ulimits                     map[string]*ulimit.Ulimit
+	RemappedRoot                string
+	CgroupParent                string
 }
 
 // InstallFlags adds command-line options to the top-level flag parser for
@@ -62,6 +72,7 @@ func (config *Config) InstallFlags() {
 	flag.BoolVar(&config.InterContainerCommunication, []string{"#icc", "-icc"}, true, "Allow unrestricted inter-container and Docker daemon host communication")
 	flag.StringVar(&config.GraphDriver, []string{"s", "-storage-driver"}, "", "Force the Docker runtime to use a specific storage driver")
 	flag.StringVar(&config.ExecDriver, []string{"e", "-exec-driver"}, "native", "Force the Docker runtime to use a specific exec driver")
+	flag.BoolVar(&config.LiveRestore, []string{"-live-restore"}, false, "Keep containers running while the daemon is down and re-attach to them when it starts again")
 	flag.BoolVar(&config.EnableSelinuxSupport, []string{"-selinux-enabled"}, false, "Enable selinux support. SELinux does not presently support the BTRFS storage driver")
 	flag.IntVar(&config.Mtu, []string{"#mtu", "-mtu"}, 0, "Set the containers network MTU\nif no value is provided: default to the default route MTU or 1500 if no default route is available")
 	opts.IPVar(&config.DefaultIp, []string{"#ip", "-ip"}, "0.0.0.0", "Default IP address to use when binding container ports")
@@ -71,6 +82,14 @@ func (config *Config) InstallFlags() {
 	opts.DnsSearchListVar(&config.DnsSearch, []string{"-dns-search"}, "Force Docker to use specific DNS search domains")
 	opts.MirrorListVar(&config.Mirrors, []string{"-registry-mirror"}, "Specify a preferred Docker registry mirror")
 	opts.LabelListVar(&config.Labels, []string{"-label"}, "Set key=value labels to the daemon (displayed in `docker info`)")
+	opts.ListVar(&config.EventWebhooks, []string{"-event-webhook"}, "POST daemon events as JSON to the given URL")
+	flag.IntVar(&config.EventWebhookBatchSize, []string{"-event-webhook-batch-size"}, 16, "Maximum number of events sent in a single webhook request")
+	flag.IntVar(&config.EventWebhookQueueSize, []string{"-event-webhook-queue-size"}, 1024, "Maximum number of undelivered events kept on disk per webhook")
+	flag.DurationVar(&config.EventWebhookFlushInterval, []string{"-event-webhook-flush-interval"}, time.Second, "Time to wait for a batch of events to fill up before sending it")
+	config.Ulimits = make(map[string]*ulimit.Ulimit)
+	flag.Var(opts.NewUlimitOpt(config.Ulimits), []string{"-default-ulimit"}, "Set default ulimits for containers (e.g., --default-ulimit nofile=1024:2048)")
+	flag.StringVar(&config.CgroupParent, []string{"-cgroup-parent"}, "", "Set the parent cgroup of the containers which do not set one with --cgroup-parent")
+	flag.StringVar(&config.RemappedRoot, []string{"-userns-remap"}, "", "Map root in the containers to the subordinate uids and gids of a user[:group] (e.g., --userns-remap=dockremap)")
 
 	// Localhost is by default considered as an insecure registry
 	// This is a stop-gap for people who are running a private registry on localhost (especially on Boot2docker).
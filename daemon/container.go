@@ -20,6 +20,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/events"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/links"
 	"github.com/docker/docker/nat"
@@ -29,7 +30,9 @@ import (
 	"github.com/docker/docker/pkg/networkfs/etchosts"
 	"github.com/docker/docker/pkg/networkfs/resolvconf"
 	"github.com/docker/docker/pkg/promise"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
@@ -80,7 +83,9 @@ type Container struct {
 	daemon                   *Daemon
 	MountLabel, ProcessLabel string
 	AppArmorProfile          string
+	SeccompProfile           string
 	RestartCount             int
+	HasBeenManuallyStopped   bool // The user stopped the container, used by the "unless-stopped" restart policy
 
 	// Maps container paths to volume paths.  The key in this is the path to which
 	// the volume is being mounted inside the container.  Value is the path of the
@@ -91,9 +96,10 @@ type Container struct {
 	VolumesRW  map[string]bool
 	hostConfig *runconfig.HostConfig
 
-	activeLinks  map[string]*links.Link
-	monitor      *containerMonitor
-	execCommands *execStore
+	activeLinks   map[string]*links.Link
+	monitor       *containerMonitor
+	healthMonitor *healthMonitor
+	execCommands  *execStore
 }
 
 func (container *Container) FromDisk() error {
@@ -185,8 +191,26 @@ func (container *Container) WriteHostConfig() error {
 }
 
 func (container *Container) LogEvent(action string) {
-	d := container.daemon
-	if err := d.eng.Job("log", action, container.ID, d.Repositories().ImageName(container.Image)).Run(); err != nil {
+	container.LogEventAttributes(action, nil)
+}
+
+// LogEventAttributes logs a container event carrying action-specific
+// attributes, on top of the container labels, name and image.
+func (container *Container) LogEventAttributes(action string, attributes map[string]string) {
+	var (
+		d     = container.daemon
+		image = d.Repositories().ImageName(container.Image)
+		attrs = make(map[string]string)
+	)
+	for k, v := range container.Config.Labels {
+		attrs[k] = v
+	}
+	attrs["name"] = strings.TrimPrefix(container.Name, "/")
+	attrs["image"] = image
+	for k, v := range attributes {
+		attrs[k] = v
+	}
+	if err := events.LogEvent(d.eng, events.ContainerEventType, action, container.ID, image, attrs); err != nil {
 		log.Errorf("Error logging event %s for %s: %s", action, container.ID, err)
 	}
 }
@@ -201,6 +225,27 @@ func (container *Container) getRootResourcePath(path string) (string, error) {
 	return symlink.FollowSymlinkInScope(filepath.Join(container.root, cleanPath), container.root)
 }
 
+// getThrottleDevices resolves the devices of the block IO throttles to their
+// major and minor numbers.
+func getThrottleDevices(throttles []*runconfig.ThrottleDevice) ([]*execdriver.ThrottleDevice, error) {
+	var devs []*execdriver.ThrottleDevice
+	for _, throttle := range throttles {
+		device, err := devices.GetDevice(throttle.Path, "")
+		if err != nil {
+			return nil, fmt.Errorf("error gathering device information while throttling %q: %s", throttle.Path, err)
+		}
+		if device.Type != 'b' {
+			return nil, fmt.Errorf("cannot throttle %q: not a block device", throttle.Path)
+		}
+		devs = append(devs, &execdriver.ThrottleDevice{
+			Major: device.MajorNumber,
+			Minor: device.MinorNumber,
+			Rate:  throttle.Rate,
+		})
+	}
+	return devs, nil
+}
+
 func populateCommand(c *Container, env []string) error {
 	en := &execdriver.Network{
 		Mtu:       c.daemon.config.Mtu,
@@ -245,6 +290,22 @@ func populateCommand(c *Container, env []string) error {
 		ipc.HostIpc = c.hostConfig.IpcMode.IsHost()
 	}
 
+	pid := &execdriver.Pid{}
+
+	if c.hostConfig.PidMode.IsContainer() {
+		pc, err := c.getPidContainer()
+		if err != nil {
+			return err
+		}
+		pid.ContainerID = pc.ID
+	} else {
+		pid.HostPid = c.hostConfig.PidMode.IsHost()
+	}
+
+	uts := &execdriver.UTS{
+		HostUTS: c.hostConfig.UTSMode.IsHost(),
+	}
+
 	// Build lists of devices allowed and created within the container.
 	userSpecifiedDevices := make([]*devices.Device, len(c.hostConfig.Devices))
 	for i, deviceMapping := range c.hostConfig.Devices {
@@ -265,11 +326,60 @@ func populateCommand(c *Container, env []string) error {
 		return err
 	}
 
+	// the ulimits of the container override the defaults of the daemon
+	var rlimits []*ulimit.Rlimit
+	ulimits := c.hostConfig.Ulimits
+	for name, ul := range c.daemon.config.Ulimits {
+		overridden := false
+		for _, u := range ulimits {
+			if u.Name == name {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			ulimits = append(ulimits, ul)
+		}
+	}
+	for _, u := range ulimits {
+		rl, err := u.GetRlimit()
+		if err != nil {
+			return err
+		}
+		rlimits = append(rlimits, rl)
+	}
+
+	readBps, err := getThrottleDevices(c.hostConfig.BlkioDeviceReadBps)
+	if err != nil {
+		return err
+	}
+	writeBps, err := getThrottleDevices(c.hostConfig.BlkioDeviceWriteBps)
+	if err != nil {
+		return err
+	}
+	readIOps, err := getThrottleDevices(c.hostConfig.BlkioDeviceReadIOps)
+	if err != nil {
+		return err
+	}
+	writeIOps, err := getThrottleDevices(c.hostConfig.BlkioDeviceWriteIOps)
+	if err != nil {
+		return err
+	}
+
 	resources := &execdriver.Resources{
-		Memory:     c.Config.Memory,
-		MemorySwap: c.Config.MemorySwap,
-		CpuShares:  c.Config.CpuShares,
-		Cpuset:     c.Config.Cpuset,
+		Memory:                       c.Config.Memory,
+		MemorySwap:                   c.Config.MemorySwap,
+		CpuShares:                    c.Config.CpuShares,
+		CpuPeriod:                    c.hostConfig.CpuPeriod,
+		CpuQuota:                     c.hostConfig.CpuQuota,
+		Cpuset:                       c.Config.Cpuset,
+		BlkioWeight:                  c.hostConfig.BlkioWeight,
+		BlkioThrottleReadBpsDevice:   readBps,
+		BlkioThrottleWriteBpsDevice:  writeBps,
+		BlkioThrottleReadIOpsDevice:  readIOps,
+		BlkioThrottleWriteIOpsDevice: writeIOps,
+		OomKillDisable:               c.hostConfig.OomKillDisable,
+		Rlimits:                      rlimits,
 	}
 
 	processConfig := execdriver.ProcessConfig{
@@ -283,6 +393,13 @@ func populateCommand(c *Container, env []string) error {
 	processConfig.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 	processConfig.Env = env
 
+	// the default profile needs seccomp, a profile given by the user is
+	// checked when the container is created
+	seccompProfile := c.SeccompProfile
+	if seccompProfile == "" && !c.daemon.SystemConfig().Seccomp {
+		seccompProfile = "unconfined"
+	}
+
 	c.command = &execdriver.Command{
 		ID:                 c.ID,
 		Rootfs:             c.RootfsPath(),
@@ -290,6 +407,8 @@ func populateCommand(c *Container, env []string) error {
 		WorkingDir:         c.Config.WorkingDir,
 		Network:            en,
 		Ipc:                ipc,
+		Pid:                pid,
+		UTS:                uts,
 		Resources:          resources,
 		AllowedDevices:     allowedDevices,
 		AutoCreatedDevices: autoCreatedDevices,
@@ -300,7 +419,15 @@ func populateCommand(c *Container, env []string) error {
 		MountLabel:         c.GetMountLabel(),
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
+		SeccompProfile:     seccompProfile,
+		ReadonlyRootfs:     c.hostConfig.ReadonlyRootfs,
+		CgroupParent:       c.hostConfig.CgroupParent,
+		Sysctls:            c.hostConfig.Sysctls,
 	}
+	if c.command.CgroupParent == "" {
+		c.command.CgroupParent = c.daemon.config.CgroupParent
+	}
+	c.command.UIDMapping, c.command.GIDMapping = c.daemon.GetUIDGIDMaps()
 
 	return nil
 }
@@ -313,6 +440,8 @@ func (container *Container) Start() (err error) {
 		return nil
 	}
 
+	container.HasBeenManuallyStopped = false
+
 	// if we encounter and error during start we need to ensure that any other
 	// setup has been cleaned up properly
 	defer func() {
@@ -361,6 +490,41 @@ func (container *Container) Start() (err error) {
 	return container.waitForStart()
 }
 
+// reattach resumes the monitoring and logging of a container left running
+// by a previous daemon with live restore enabled.
+func (container *Container) reattach() (err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	defer func() {
+		if err != nil {
+			container.setStopped(&execdriver.ExitStatus{ExitCode: -1})
+			container.toDisk()
+			container.cleanup()
+		}
+	}()
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	if err := container.RestoreNetwork(); err != nil {
+		return err
+	}
+	if err := populateCommand(container, container.createDaemonEnvironment(nil)); err != nil {
+		return err
+	}
+	// allow the container to be killed before the driver re-attached to it
+	if container.command.ProcessConfig.Process, err = os.FindProcess(container.Pid); err != nil {
+		return err
+	}
+
+	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	container.monitor.reattaching = true
+	go container.monitor.Start()
+
+	return nil
+}
+
 func (container *Container) Run() error {
 	if err := container.Start(); err != nil {
 		return err
@@ -543,6 +707,8 @@ func (container *Container) AllocateNetwork() error {
 	container.NetworkSettings.MacAddress = env.Get("MacAddress")
 	container.NetworkSettings.Gateway = env.Get("Gateway")
 
+	container.logNetworkEvent("allocate")
+
 	return nil
 }
 
@@ -555,9 +721,20 @@ func (container *Container) ReleaseNetwork() {
 	job := eng.Job("release_interface", container.ID)
 	job.SetenvBool("overrideShutdown", true)
 	job.Run()
+	container.logNetworkEvent("release")
 	container.NetworkSettings = &NetworkSettings{}
 }
 
+func (container *Container) logNetworkEvent(action string) {
+	attrs := map[string]string{
+		"container": container.ID,
+		"ip":        container.NetworkSettings.IPAddress,
+	}
+	if err := events.LogEvent(container.daemon.eng, events.NetworkEventType, action, container.NetworkSettings.Bridge, "", attrs); err != nil {
+		log.Errorf("Error logging network event %s for %s: %s", action, container.ID, err)
+	}
+}
+
 func (container *Container) isNetworkAllocated() bool {
 	return container.NetworkSettings.IPAddress != ""
 }
@@ -659,6 +836,16 @@ func (container *Container) Unpause() error {
 	return container.daemon.Unpause(container)
 }
 
+// setManuallyStopped records that the user asked for the container to be
+// stopped, so that it is not restarted with the daemon.
+func (container *Container) setManuallyStopped() {
+	container.Lock()
+	if container.Running {
+		container.HasBeenManuallyStopped = true
+	}
+	container.Unlock()
+}
+
 func (container *Container) Kill() error {
 	if !container.IsRunning() {
 		return nil
@@ -689,9 +876,10 @@ func (container *Container) Stop(seconds int) error {
 		return nil
 	}
 
-	// 1. Send a SIGTERM
-	if err := container.KillSig(15); err != nil {
-		log.Infof("Failed to send SIGTERM to the process, force killing")
+	// 1. Send the stop signal, SIGTERM unless the image sets another one
+	stopSignal := container.stopSignal()
+	if err := container.KillSig(int(stopSignal)); err != nil {
+		log.Infof("Failed to send signal %d to the process, force killing", stopSignal)
 		if err := container.KillSig(9); err != nil {
 			return err
 		}
@@ -699,7 +887,7 @@ func (container *Container) Stop(seconds int) error {
 
 	// 2. Wait for the process to exit on its own
 	if _, err := container.WaitStop(time.Duration(seconds) * time.Second); err != nil {
-		log.Infof("Container %v failed to exit within %d seconds of SIGTERM - using the force", container.ID, seconds)
+		log.Infof("Container %v failed to exit within %d seconds of signal %d - using the force", container.ID, seconds, stopSignal)
 		// 3. If it doesn't, then send SIGKILL
 		if err := container.Kill(); err != nil {
 			container.WaitStop(-1 * time.Second)
@@ -709,6 +897,19 @@ func (container *Container) Stop(seconds int) error {
 	return nil
 }
 
+// stopSignal returns the signal sent by Stop, the one of the config or
+// SIGTERM.
+func (container *Container) stopSignal() syscall.Signal {
+	if container.Config.StopSignal != "" {
+		sig, err := signal.ParseSignal(container.Config.StopSignal)
+		if err == nil {
+			return sig
+		}
+		log.Errorf("Invalid stop signal of %s: %s", container.ID, err)
+	}
+	return syscall.SIGTERM
+}
+
 func (container *Container) Restart(seconds int) error {
 	// Avoid unnecessarily unmounting and then directly mounting
 	// the container when the container stops and then starts
@@ -1079,6 +1280,9 @@ func (container *Container) verifyDaemonSettings() {
 		log.Infof("WARNING: Your kernel does not support swap limit capabilities. Limitation discarded.")
 		container.Config.MemorySwap = -1
 	}
+	for _, warning := range verifyHostResources(container.daemon.sysInfo, container.hostConfig) {
+		log.Infof("WARNING: %s", warning)
+	}
 	if container.daemon.sysInfo.IPv4ForwardingDisabled {
 		log.Infof("WARNING: IPv4 forwarding is disabled. Networking will not work")
 	}
@@ -1282,6 +1486,18 @@ func (container *Container) getIpcContainer() (*Container, error) {
 	return c, nil
 }
 
+func (container *Container) getPidContainer() (*Container, error) {
+	containerID := container.hostConfig.PidMode.Container()
+	c := container.daemon.Get(containerID)
+	if c == nil {
+		return nil, fmt.Errorf("no such container to join PID: %s", containerID)
+	}
+	if !c.IsRunning() {
+		return nil, fmt.Errorf("cannot join PID of a non running container: %s", containerID)
+	}
+	return c, nil
+}
+
 func (container *Container) getNetworkedContainer() (*Container, error) {
 	parts := strings.SplitN(string(container.hostConfig.NetworkMode), ":", 2)
 	switch parts[0] {
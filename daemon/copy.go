@@ -24,6 +24,8 @@ func (daemon *Daemon) ContainerCopy(job *engine.Job) engine.Status {
 		}
 		defer data.Close()
 
+		container.LogEventAttributes("copy", map[string]string{"path": resource})
+
 		if _, err := io.Copy(job.Stdout, data); err != nil {
 			return job.Error(err)
 		}
@@ -6,6 +6,7 @@ import (
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/libcontainer/label"
 )
@@ -80,8 +81,14 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 	if warnings, err = daemon.mergeAndVerifyConfig(config, img); err != nil {
 		return nil, nil, err
 	}
+	if err := runconfig.ValidateResources(config, hostConfig); err != nil {
+		return nil, nil, err
+	}
+	if hostConfig != nil {
+		warnings = append(warnings, verifyHostResources(daemon.SystemConfig(), hostConfig)...)
+	}
 	if hostConfig != nil && hostConfig.SecurityOpt == nil {
-		hostConfig.SecurityOpt, err = daemon.GenerateSecurityOpt(hostConfig.IpcMode)
+		hostConfig.SecurityOpt, err = daemon.GenerateSecurityOpt(hostConfig.IpcMode, hostConfig.PidMode)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -113,8 +120,8 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 	return container, warnings, nil
 }
 
-func (daemon *Daemon) GenerateSecurityOpt(ipcMode runconfig.IpcMode) ([]string, error) {
-	if ipcMode.IsHost() {
+func (daemon *Daemon) GenerateSecurityOpt(ipcMode runconfig.IpcMode, pidMode runconfig.PidMode) ([]string, error) {
+	if ipcMode.IsHost() || pidMode.IsHost() {
 		return label.DisableSecOpt(), nil
 	}
 	if ipcContainer := ipcMode.Container(); ipcContainer != "" {
@@ -128,5 +135,44 @@ func (daemon *Daemon) GenerateSecurityOpt(ipcMode runconfig.IpcMode) ([]string,
 
 		return label.DupSecOpt(c.ProcessLabel), nil
 	}
+	if pidContainer := pidMode.Container(); pidContainer != "" {
+		c := daemon.Get(pidContainer)
+		if c == nil {
+			return nil, fmt.Errorf("no such container to join PID: %s", pidContainer)
+		}
+		if !c.IsRunning() {
+			return nil, fmt.Errorf("cannot join PID of a non running container: %s", pidContainer)
+		}
+
+		return label.DupSecOpt(c.ProcessLabel), nil
+	}
 	return nil, nil
 }
+
+// verifyHostResources discards the resource limits of hostConfig that the
+// kernel does not support and returns the warnings to show to the user.
+func verifyHostResources(sysInfo *sysinfo.SysInfo, hostConfig *runconfig.HostConfig) []string {
+	var warnings []string
+	if (hostConfig.CpuPeriod != 0 && !sysInfo.CpuCfsPeriod) || (hostConfig.CpuQuota != 0 && !sysInfo.CpuCfsQuota) {
+		warnings = append(warnings, "Your kernel does not support CPU CFS scheduler limits. Limitation discarded.")
+		hostConfig.CpuPeriod = 0
+		hostConfig.CpuQuota = 0
+	}
+	if hostConfig.BlkioWeight != 0 && !sysInfo.BlkioWeight {
+		warnings = append(warnings, "Your kernel does not support block IO weight. Weight discarded.")
+		hostConfig.BlkioWeight = 0
+	}
+	if (len(hostConfig.BlkioDeviceReadBps) > 0 || len(hostConfig.BlkioDeviceWriteBps) > 0 ||
+		len(hostConfig.BlkioDeviceReadIOps) > 0 || len(hostConfig.BlkioDeviceWriteIOps) > 0) && !sysInfo.BlkioThrottle {
+		warnings = append(warnings, "Your kernel does not support block IO throttling. Limitation discarded.")
+		hostConfig.BlkioDeviceReadBps = nil
+		hostConfig.BlkioDeviceWriteBps = nil
+		hostConfig.BlkioDeviceReadIOps = nil
+		hostConfig.BlkioDeviceWriteIOps = nil
+	}
+	if hostConfig.OomKillDisable && !sysInfo.OomKillDisable {
+		warnings = append(warnings, "Your kernel does not support OOM killer control. OOM killer kept enabled.")
+		hostConfig.OomKillDisable = false
+	}
+	return warnings
+}
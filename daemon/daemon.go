@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/docker/libcontainer/label"
+	"github.com/docker/libcontainer/security/seccomp"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
@@ -25,11 +27,13 @@ import (
 	"github.com/docker/docker/daemon/networkdriver/portallocator"
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/events"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/broadcastwriter"
 	"github.com/docker/docker/pkg/graphdb"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/docker/docker/pkg/parsers"
@@ -100,6 +104,8 @@ type Daemon struct {
 	driver         graphdriver.Driver
 	execDriver     execdriver.Driver
 	trustStore     *trust.TrustStore
+	uidMaps        []idtools.IDMap
+	gidMaps        []idtools.IDMap
 }
 
 // Install installs daemon capabilities to eng.
@@ -230,6 +236,10 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 	// FIXME: if the container is supposed to be running but is not, auto restart it?
 	//        if so, then we need to restart monitor and init a new lock
 	// If the container is supposed to be running, make sure of it
+	if container.IsRunning() && daemon.config.LiveRestore && container.ExecDriver == daemon.execDriver.Name() {
+		// the container is re-attached to once all the containers are registered
+		return nil
+	}
 	if container.IsRunning() {
 		log.Debugf("killing old running container %s", container.ID)
 
@@ -373,14 +383,37 @@ func (daemon *Daemon) restore() error {
 		registeredContainers = append(registeredContainers, container)
 	}
 
+	// re-attach to the containers left running by the previous daemon before
+	// other containers are started and take their network addresses, their
+	// restart policy is then applied by their monitor
+	reattached := make(map[string]bool)
+	if daemon.config.LiveRestore {
+		for _, container := range registeredContainers {
+			if !container.IsRunning() {
+				continue
+			}
+			log.Debugf("Re-attaching to container %s", container.ID)
+			if err := container.reattach(); err != nil {
+				log.Errorf("Failed to re-attach to container %s: %s", container.ID, err)
+			}
+			reattached[container.ID] = true
+		}
+	}
+
 	// check the restart policy on the containers and restart any container with
-	// the restart policy of "always"
+	// the restart policy of "always", or "unless-stopped" if the user did not
+	// stop it
 	if daemon.config.AutoRestart {
 		log.Debugf("Restarting containers...")
 
 		for _, container := range registeredContainers {
-			if container.hostConfig.RestartPolicy.Name == "always" ||
-				(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0) {
+			if reattached[container.ID] {
+				continue
+			}
+			policy := container.hostConfig.RestartPolicy
+			if policy.Name == "always" ||
+				(policy.Name == "unless-stopped" && !container.HasBeenManuallyStopped) ||
+				(policy.Name == "on-failure" && container.ExitCode != 0) {
 				log.Debugf("Starting container %s", container.ID)
 
 				if err := container.Start(); err != nil {
@@ -539,15 +572,22 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	)
 
 	for _, opt := range config.SecurityOpt {
-		con := strings.SplitN(opt, ":", 2)
-		if len(con) == 1 {
+		// the value of seccomp is a JSON profile, split at the first separator
+		i := strings.IndexAny(opt, ":=")
+		if i == -1 {
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
+		con := []string{opt[:i], opt[i+1:]}
 		switch con[0] {
 		case "label":
 			labelOpts = append(labelOpts, con[1])
 		case "apparmor":
 			container.AppArmorProfile = con[1]
+		case "seccomp":
+			if err := validateSeccompProfile(con[1]); err != nil {
+				return err
+			}
+			container.SeccompProfile = con[1]
 		default:
 			return fmt.Errorf("Invalid --security-opt: %q", opt)
 		}
@@ -557,6 +597,22 @@ func parseSecurityOpt(container *Container, config *runconfig.HostConfig) error
 	return err
 }
 
+// validateSeccompProfile checks a JSON seccomp profile, "unconfined"
+// disables the filter
+func validateSeccompProfile(profile string) error {
+	if profile == "unconfined" {
+		return nil
+	}
+	config := &seccomp.Seccomp{}
+	if err := json.Unmarshal([]byte(profile), config); err != nil {
+		return fmt.Errorf("Invalid seccomp profile: %s", err)
+	}
+	if err := seccomp.Validate(config); err != nil {
+		return fmt.Errorf("Invalid seccomp profile: %s", err)
+	}
+	return nil
+}
+
 func (daemon *Daemon) newContainer(name string, config *runconfig.Config, img *image.Image) (*Container, error) {
 	var (
 		id  string
@@ -596,6 +652,10 @@ func (daemon *Daemon) createRootfs(container *Container, img *image.Image) error
 	if err := os.Mkdir(container.root, 0700); err != nil {
 		return err
 	}
+	rootUID, rootGID := daemon.GetRemappedUIDGID()
+	if err := os.Chown(container.root, rootUID, rootGID); err != nil {
+		return err
+	}
 	initID := fmt.Sprintf("%s-init", container.ID)
 	if err := daemon.driver.Create(initID, img.ID); err != nil {
 		return err
@@ -606,7 +666,7 @@ func (daemon *Daemon) createRootfs(container *Container, img *image.Image) error
 	}
 	defer daemon.driver.Put(initID)
 
-	if err := graph.SetupInitLayer(initPath); err != nil {
+	if err := graph.SetupInitLayer(initPath, rootUID, rootGID); err != nil {
 		return err
 	}
 
@@ -787,8 +847,33 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		}
 	}
 	config.Root = realRoot
+	sharedRoot := realRoot
+
+	uidMaps, gidMaps, err := parseRemappedRoot(config.RemappedRoot)
+	if err != nil {
+		return nil, err
+	}
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
+	if config.RemappedRoot != "" {
+		if config.ExecDriver != "native" {
+			return nil, fmt.Errorf("--userns-remap is only supported by the native exec driver")
+		}
+		// The images and containers of a remapped root are kept apart in a
+		// directory owned by the remapped root, which the containers can
+		// reach through the shared root.
+		if err := os.MkdirAll(sharedRoot, 0711); err != nil && !os.IsExist(err) {
+			return nil, err
+		}
+		if err := os.Chmod(sharedRoot, 0711); err != nil {
+			return nil, err
+		}
+		config.Root = path.Join(sharedRoot, fmt.Sprintf("%d.%d", rootUID, rootGID))
+	}
 	// Create the root directory if it doesn't exists
-	if err := os.MkdirAll(config.Root, 0700); err != nil && !os.IsExist(err) {
+	if err := idtools.MkdirAllAs(config.Root, 0700, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
@@ -796,7 +881,7 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 	graphdriver.DefaultDriver = config.GraphDriver
 
 	// Load storage driver
-	driver, err := graphdriver.New(config.Root, config.GraphOptions)
+	driver, err := graphdriver.New(config.Root, config.GraphOptions, uidMaps, gidMaps)
 	if err != nil {
 		return nil, err
 	}
@@ -809,7 +894,7 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 
 	daemonRepo := path.Join(config.Root, "containers")
 
-	if err := os.MkdirAll(daemonRepo, 0700); err != nil && !os.IsExist(err) {
+	if err := idtools.MkdirAllAs(daemonRepo, 0700, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
@@ -824,7 +909,7 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		return nil, err
 	}
 
-	volumesDriver, err := graphdriver.GetDriver("vfs", config.Root, config.GraphOptions)
+	volumesDriver, err := graphdriver.GetDriver("vfs", config.Root, config.GraphOptions, uidMaps, gidMaps)
 	if err != nil {
 		return nil, err
 	}
@@ -866,13 +951,25 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		}
 	}
 
+	if len(config.EventWebhooks) > 0 {
+		job := eng.Job("events_webhooks", config.EventWebhooks...)
+		job.Setenv("QueueDir", path.Join(config.Root, "events"))
+		job.SetenvInt("QueueSize", config.EventWebhookQueueSize)
+		job.SetenvInt("BatchSize", config.EventWebhookBatchSize)
+		job.Setenv("FlushInterval", config.EventWebhookFlushInterval.String())
+		if err := job.Run(); err != nil {
+			return nil, err
+		}
+	}
+
 	graphdbPath := path.Join(config.Root, "linkgraph.db")
 	graph, err := graphdb.NewSqliteConn(graphdbPath)
 	if err != nil {
 		return nil, err
 	}
 
-	localCopy := path.Join(config.Root, "init", fmt.Sprintf("dockerinit-%s", dockerversion.VERSION))
+	// dockerinit is kept in the shared root, out of reach of a remapped root
+	localCopy := path.Join(sharedRoot, "init", fmt.Sprintf("dockerinit-%s", dockerversion.VERSION))
 	sysInitPath := utils.DockerInitPath(localCopy)
 	if sysInitPath == "" {
 		return nil, fmt.Errorf("Could not locate dockerinit: This usually means docker was built incorrectly. See http://docs.docker.com/contributing/devenvironment for official build instructions.")
@@ -891,9 +988,22 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		}
 		sysInitPath = localCopy
 	}
+	if config.RemappedRoot != "" {
+		// the init of the containers runs as the remapped root
+		if err := os.Chmod(path.Dir(localCopy), 0711); err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(localCopy, 0755); err != nil {
+			return nil, err
+		}
+		// the init reads its configuration from the exec driver root
+		if err := idtools.MkdirAllAs(path.Join(config.Root, "execdriver", "native"), 0700, rootUID, rootGID); err != nil {
+			return nil, err
+		}
+	}
 
 	sysInfo := sysinfo.New(false)
-	ed, err := execdrivers.NewDriver(config.ExecDriver, config.Root, sysInitPath, sysInfo)
+	ed, err := execdrivers.NewDriver(config.ExecDriver, config.Root, sysInitPath, config.LiveRestore, sysInfo)
 	if err != nil {
 		return nil, err
 	}
@@ -920,6 +1030,8 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		execDriver:     ed,
 		eng:            eng,
 		trustStore:     t,
+		uidMaps:        uidMaps,
+		gidMaps:        gidMaps,
 	}
 	if err := daemon.restore(); err != nil {
 		return nil, err
@@ -943,10 +1055,19 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine) (*Daemon, error)
 		}
 	})
 
+	if err := events.LogEvent(eng, events.DaemonEventType, "start", daemon.ID, "", nil); err != nil {
+		log.Errorf("Error logging daemon start event: %s", err)
+	}
+
 	return daemon, nil
 }
 
 func (daemon *Daemon) shutdown() error {
+	if daemon.config.LiveRestore {
+		log.Debugf("live restore enabled, leaving the containers running")
+		return nil
+	}
+
 	group := sync.WaitGroup{}
 	log.Debugf("starting clean shutdown of all containers...")
 	for _, container := range daemon.List() {
@@ -1004,6 +1125,10 @@ func (daemon *Daemon) Run(c *Container, pipes *execdriver.Pipes, startCallback e
 	return daemon.execDriver.Run(c.command, pipes, startCallback)
 }
 
+func (daemon *Daemon) Reattach(c *Container, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	return daemon.execDriver.Reattach(c.command, pipes, startCallback)
+}
+
 func (daemon *Daemon) Pause(c *Container) error {
 	if err := daemon.execDriver.Pause(c.command); err != nil {
 		return err
@@ -1080,36 +1205,11 @@ func (daemon *Daemon) ContainerGraph() *graphdb.Database {
 	return daemon.containerGraph
 }
 
-func (daemon *Daemon) ImageGetCached(imgID string, config *runconfig.Config) (*image.Image, error) {
-	// Retrieve all images
-	images, err := daemon.Graph().Map()
-	if err != nil {
-		return nil, err
-	}
-
-	// Store the tree in a map of map (map[parentId][childId])
-	imageMap := make(map[string]map[string]struct{})
-	for _, img := range images {
-		if _, exists := imageMap[img.Parent]; !exists {
-			imageMap[img.Parent] = make(map[string]struct{})
-		}
-		imageMap[img.Parent][img.ID] = struct{}{}
-	}
-
-	// Loop on the children of the given image and check the config
-	var match *image.Image
-	for elem := range imageMap[imgID] {
-		img, err := daemon.Graph().Get(elem)
-		if err != nil {
-			return nil, err
-		}
-		if runconfig.Compare(&img.ContainerConfig, config) {
-			if match == nil || match.Created.Before(img.Created) {
-				match = img
-			}
-		}
-	}
-	return match, nil
+// ImageGetCached returns the most recent image built on top of imgID from a
+// container with config. When cacheFrom is not nil, only its images are
+// used as the build cache.
+func (daemon *Daemon) ImageGetCached(imgID string, config *runconfig.Config, cacheFrom map[string]struct{}) (*image.Image, error) {
+	return daemon.Graph().GetCached(imgID, config, cacheFrom)
 }
 
 func checkKernelAndArch() error {
@@ -3,6 +3,7 @@ package daemon
 import (
 	"testing"
 
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -36,4 +37,74 @@ func TestParseSecurityOpt(t *testing.T) {
 	if err := parseSecurityOpt(container, config); err == nil {
 		t.Fatal("Expected parseSecurityOpt error, got nil")
 	}
+
+	// test seccomp
+	profile := `{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "mount", "action": "SCMP_ACT_ERRNO"}]}`
+	config.SecurityOpt = []string{"seccomp=" + profile}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != profile {
+		t.Fatalf("Unexpected SeccompProfile, expected: %q, got %q", profile, container.SeccompProfile)
+	}
+
+	config.SecurityOpt = []string{"seccomp=unconfined"}
+	if err := parseSecurityOpt(container, config); err != nil {
+		t.Fatalf("Unexpected parseSecurityOpt error: %v", err)
+	}
+	if container.SeccompProfile != "unconfined" {
+		t.Fatalf("Unexpected SeccompProfile, expected: \"unconfined\", got %q", container.SeccompProfile)
+	}
+
+	// test invalid seccomp profiles
+	for _, opt := range []string{
+		"seccomp=notjson",
+		`seccomp={"defaultAction": "SCMP_ACT_FOO"}`,
+		`seccomp={"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "notasyscall", "action": "SCMP_ACT_ERRNO"}]}`,
+	} {
+		config.SecurityOpt = []string{opt}
+		if err := parseSecurityOpt(container, config); err == nil {
+			t.Fatalf("Expected parseSecurityOpt error for %q, got nil", opt)
+		}
+	}
+}
+
+func TestVerifyRemappedHostConfig(t *testing.T) {
+	daemon := &Daemon{}
+	if err := daemon.verifyRemappedHostConfig(&runconfig.HostConfig{Privileged: true}); err != nil {
+		t.Fatalf("Unexpected error without remapping: %v", err)
+	}
+
+	daemon.uidMaps = []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	daemon.gidMaps = daemon.uidMaps
+	if err := daemon.verifyRemappedHostConfig(&runconfig.HostConfig{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, config := range []*runconfig.HostConfig{
+		{Privileged: true},
+		{NetworkMode: "host"},
+		{NetworkMode: "container:foo"},
+		{IpcMode: "host"},
+		{PidMode: "host"},
+		{UTSMode: "host"},
+	} {
+		if err := daemon.verifyRemappedHostConfig(config); err == nil {
+			t.Fatalf("Expected an error for %+v", config)
+		}
+	}
+
+	if uid, gid := daemon.GetRemappedUIDGID(); uid != 100000 || gid != 100000 {
+		t.Fatalf("Expected root to be remapped to 100000:100000, got %d:%d", uid, gid)
+	}
+}
+
+func TestParseRemappedRoot(t *testing.T) {
+	if uidMaps, gidMaps, err := parseRemappedRoot(""); err != nil || uidMaps != nil || gidMaps != nil {
+		t.Fatalf("Expected no maps without --userns-remap, got %v %v (%v)", uidMaps, gidMaps, err)
+	}
+	for _, remappedRoot := range []string{":", "user:", ":group"} {
+		if _, _, err := parseRemappedRoot(remappedRoot); err == nil {
+			t.Fatalf("Expected an error parsing %q", remappedRoot)
+		}
+	}
 }
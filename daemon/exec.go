@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -146,6 +147,8 @@ func (d *Daemon) ContainerExecCreate(job *engine.Job) engine.Status {
 
 	d.registerExecCommand(execConfig)
 
+	container.LogEventAttributes("exec_create", map[string]string{"execID": execConfig.ID})
+
 	job.Printf("%s\n", execConfig.ID)
 
 	return engine.StatusOK
@@ -182,6 +185,8 @@ func (d *Daemon) ContainerExecStart(job *engine.Job) engine.Status {
 	log.Debugf("starting exec command %s in container %s", execConfig.ID, execConfig.Container.ID)
 	container := execConfig.Container
 
+	container.LogEventAttributes("exec_start", map[string]string{"execID": execConfig.ID})
+
 	if execConfig.OpenStdin {
 		r, w := io.Pipe()
 		go func() {
@@ -294,6 +299,7 @@ func (container *Container) monitorExec(execConfig *execConfig, callback execdri
 	}
 
 	log.Debugf("Exec task in container %s exited with code %d", container.ID, exitCode)
+	container.LogEventAttributes("exec_die", map[string]string{"execID": execConfig.ID, "exitCode": strconv.Itoa(exitCode)})
 	if execConfig.OpenStdin {
 		if err := execConfig.StreamConfig.stdin.Close(); err != nil {
 			log.Errorf("Error closing stdin while running in %s: %s", container.ID, err)
@@ -2,10 +2,13 @@ package execdriver
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
 
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/libcontainer/devices"
 )
 
@@ -51,6 +54,8 @@ type ExitStatus struct {
 
 type Driver interface {
 	Run(c *Command, pipes *Pipes, startCallback StartCallback) (ExitStatus, error) // Run executes the process and blocks until the process exits and returns the exit code
+	// Reattach connects to a container left running by a previous daemon, blocks until the process exits and returns the exit code
+	Reattach(c *Command, pipes *Pipes, startCallback StartCallback) (ExitStatus, error)
 	// Exec executes the process in an existing container, blocks until the process exits and returns the exit code
 	Exec(c *Command, processConfig *ProcessConfig, pipes *Pipes, startCallback StartCallback) (int, error)
 	Kill(c *Command, sig int) error
@@ -77,6 +82,17 @@ type Ipc struct {
 	HostIpc     bool   `json:"host_ipc"`
 }
 
+// PID settings of the container
+type Pid struct {
+	ContainerID string `json:"container_id"` // id of the container to join pid.
+	HostPid     bool   `json:"host_pid"`
+}
+
+// UTS settings of the container
+type UTS struct {
+	HostUTS bool `json:"host_uts"`
+}
+
 type NetworkInterface struct {
 	Gateway     string `json:"gateway"`
 	IPAddress   string `json:"ip"`
@@ -86,10 +102,31 @@ type NetworkInterface struct {
 }
 
 type Resources struct {
-	Memory     int64  `json:"memory"`
-	MemorySwap int64  `json:"memory_swap"`
-	CpuShares  int64  `json:"cpu_shares"`
-	Cpuset     string `json:"cpuset"`
+	Memory                       int64             `json:"memory"`
+	MemorySwap                   int64             `json:"memory_swap"`
+	CpuShares                    int64             `json:"cpu_shares"`
+	CpuPeriod                    int64             `json:"cpu_period"`
+	CpuQuota                     int64             `json:"cpu_quota"`
+	Cpuset                       string            `json:"cpuset"`
+	BlkioWeight                  int64             `json:"blkio_weight"`
+	BlkioThrottleReadBpsDevice   []*ThrottleDevice `json:"blkio_throttle_read_bps_device"`
+	BlkioThrottleWriteBpsDevice  []*ThrottleDevice `json:"blkio_throttle_write_bps_device"`
+	BlkioThrottleReadIOpsDevice  []*ThrottleDevice `json:"blkio_throttle_read_iops_device"`
+	BlkioThrottleWriteIOpsDevice []*ThrottleDevice `json:"blkio_throttle_write_iops_device"`
+	OomKillDisable               bool              `json:"oom_kill_disable"`
+	Rlimits                      []*ulimit.Rlimit  `json:"rlimits"`
+}
+
+// ThrottleDevice limits the rate of the block IO on a device, in bytes or
+// in operations per second.
+type ThrottleDevice struct {
+	Major int64  `json:"major"`
+	Minor int64  `json:"minor"`
+	Rate  uint64 `json:"rate"`
+}
+
+func (t *ThrottleDevice) String() string {
+	return fmt.Sprintf("%d:%d %d", t.Major, t.Minor, t.Rate)
 }
 
 type Mount struct {
@@ -98,6 +135,8 @@ type Mount struct {
 	Writable    bool   `json:"writable"`
 	Private     bool   `json:"private"`
 	Slave       bool   `json:"slave"`
+	Type        string `json:"type"` // filesystem type of the mount, a bind mount of Source if empty
+	Data        string `json:"data"` // fstab type options of a mount which is not a bind mount
 }
 
 // Describes a process that will be run inside a container.
@@ -122,6 +161,8 @@ type Command struct {
 	ConfigPath         string            `json:"config_path"` // this should be able to be removed when the lxc template is moved into the driver
 	Network            *Network          `json:"network"`
 	Ipc                *Ipc              `json:"ipc"`
+	Pid                *Pid              `json:"pid"`
+	UTS                *UTS              `json:"uts"`
 	Resources          *Resources        `json:"resources"`
 	Mounts             []Mount           `json:"mounts"`
 	AllowedDevices     []*devices.Device `json:"allowed_devices"`
@@ -134,4 +175,10 @@ type Command struct {
 	MountLabel         string            `json:"mount_label"`
 	LxcConfig          []string          `json:"lxc_config"`
 	AppArmorProfile    string            `json:"apparmor_profile"`
+	SeccompProfile     string            `json:"seccomp_profile"`
+	ReadonlyRootfs     bool              `json:"readonly_rootfs"` // mount the root filesystem read only, mounts stay writable
+	UIDMapping         []idtools.IDMap   `json:"uidmapping"`      // maps the uids of a new user namespace to the host, none if nil
+	GIDMapping         []idtools.IDMap   `json:"gidmapping"`
+	CgroupParent       string            `json:"cgroup_parent"` // the parent cgroup of the container, the driver default if empty
+	Sysctls            map[string]string `json:"sysctls"`       // namespaced kernel parameters set by the init of the container
 }
@@ -29,6 +29,10 @@ const DriverName = "lxc"
 
 var ErrExec = errors.New("Unsupported: Exec is not supported by the lxc driver")
 
+var ErrPidNamespace = errors.New("Unsupported: sharing the PID namespace is not supported by the lxc driver")
+
+var ErrUserNamespace = errors.New("Unsupported: remapping the user namespace is not supported by the lxc driver")
+
 type driver struct {
 	root       string // root path for the driver to use
 	initPath   string
@@ -61,6 +65,14 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		err  error
 	)
 
+	if c.Pid.HostPid || c.Pid.ContainerID != "" {
+		return execdriver.ExitStatus{ExitCode: -1}, ErrPidNamespace
+	}
+
+	if c.UIDMapping != nil || c.GIDMapping != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, ErrUserNamespace
+	}
+
 	if c.ProcessConfig.Tty {
 		term, err = NewTtyConsole(&c.ProcessConfig, pipes)
 	} else {
@@ -92,6 +104,11 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 			"--share-net", c.Network.ContainerID,
 		)
 	}
+	if c.UTS.HostUTS {
+		params = append(params,
+			"--share-uts", "1",
+		)
+	}
 
 	params = append(params,
 		"--",
@@ -123,6 +140,22 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		params = append(params, "-w", c.WorkingDir)
 	}
 
+	if c.Resources != nil && len(c.Resources.Rlimits) > 0 {
+		rlimits := make([]string, 0, len(c.Resources.Rlimits))
+		for _, rlimit := range c.Resources.Rlimits {
+			rlimits = append(rlimits, fmt.Sprintf("%d=%d:%d", rlimit.Type, rlimit.Soft, rlimit.Hard))
+		}
+		params = append(params, "-rlimits", strings.Join(rlimits, ","))
+	}
+
+	if len(c.Sysctls) > 0 {
+		sysctls, err := json.Marshal(c.Sysctls)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		params = append(params, "-sysctls", string(sysctls))
+	}
+
 	params = append(params, "--", c.ProcessConfig.Entrypoint)
 	params = append(params, c.ProcessConfig.Arguments...)
 
@@ -205,6 +238,10 @@ func getExitCode(c *execdriver.Command) int {
 	return c.ProcessConfig.ProcessState.Sys().(syscall.WaitStatus).ExitStatus()
 }
 
+func (d *driver) Reattach(c *execdriver.Command, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("%s driver cannot re-attach to container %s", DriverName, c.ID)
+}
+
 func (d *driver) Kill(c *execdriver.Command, sig int) error {
 	return KillLxc(c.ID, sig)
 }
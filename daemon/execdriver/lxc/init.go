@@ -13,6 +13,7 @@ import (
 	"syscall"
 
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/libcontainer/system"
 )
 
 // Args provided to the init function for a driver
@@ -30,6 +31,8 @@ type InitArgs struct {
 	Root       string
 	CapAdd     string
 	CapDrop    string
+	Rlimits    string
+	Sysctls    string
 }
 
 func init() {
@@ -86,6 +89,8 @@ func getArgs() *InitArgs {
 		mtu        = flag.Int("mtu", 1500, "interface mtu")
 		capAdd     = flag.String("cap-add", "", "capabilities to add")
 		capDrop    = flag.String("cap-drop", "", "capabilities to drop")
+		rlimits    = flag.String("rlimits", "", "resource limits as type=soft:hard pairs")
+		sysctls    = flag.String("sysctls", "", "kernel parameters as a JSON object of the values by key")
 	)
 
 	flag.Parse()
@@ -100,6 +105,8 @@ func getArgs() *InitArgs {
 		Mtu:        *mtu,
 		CapAdd:     *capAdd,
 		CapDrop:    *capDrop,
+		Rlimits:    *rlimits,
+		Sysctls:    *sysctls,
 	}
 }
 
@@ -151,3 +158,46 @@ func getEnv(args *InitArgs, key string) string {
 	}
 	return ""
 }
+
+// setupRlimits applies the resource limits passed to dockerinit
+// as a comma separated list of type=soft:hard values
+func setupRlimits(args *InitArgs) error {
+	if args.Rlimits == "" {
+		return nil
+	}
+
+	for _, value := range strings.Split(args.Rlimits, ",") {
+		var (
+			typ        int
+			soft, hard uint64
+		)
+		if _, err := fmt.Sscanf(value, "%d=%d:%d", &typ, &soft, &hard); err != nil {
+			return fmt.Errorf("invalid rlimit %q: %s", value, err)
+		}
+		if err := syscall.Setrlimit(typ, &syscall.Rlimit{Cur: soft, Max: hard}); err != nil {
+			return fmt.Errorf("error setting rlimit type %d: %s", typ, err)
+		}
+	}
+
+	return nil
+}
+
+// setupSysctls sets the kernel parameters passed to dockerinit as a JSON
+// object of the values by key
+func setupSysctls(args *InitArgs) error {
+	if args.Sysctls == "" {
+		return nil
+	}
+
+	var sysctls map[string]string
+	if err := json.Unmarshal([]byte(args.Sysctls), &sysctls); err != nil {
+		return fmt.Errorf("invalid sysctls %q: %s", args.Sysctls, err)
+	}
+	for key, value := range sysctls {
+		if err := system.WriteSysctl(key, value); err != nil {
+			return fmt.Errorf("error setting sysctl %s to %q: %s", key, value, err)
+		}
+	}
+
+	return nil
+}
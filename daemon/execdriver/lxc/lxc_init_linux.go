@@ -11,6 +11,14 @@ func finalizeNamespace(args *InitArgs) error {
 		return err
 	}
 
+	if err := setupRlimits(args); err != nil {
+		return err
+	}
+
+	if err := setupSysctls(args); err != nil {
+		return err
+	}
+
 	if err := namespaces.SetupUser(args.User); err != nil {
 		return fmt.Errorf("setup user %s", err)
 	}
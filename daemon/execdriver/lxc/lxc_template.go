@@ -35,6 +35,9 @@ lxc.network.mtu = {{.Network.Mtu}}
 # root filesystem
 {{$ROOTFS := .Rootfs}}
 lxc.rootfs = {{$ROOTFS}}
+{{if .ReadonlyRootfs}}
+lxc.rootfs.options = ro
+{{end}}
 
 # use a dedicated pts for the container (and limit the number of pseudo terminal
 # available)
@@ -79,14 +82,16 @@ lxc.mount.entry = shm {{escapeFstabSpaces $ROOTFS}}/dev/shm tmpfs {{formatMountL
 
 {{range $value := .Mounts}}
 {{$createVal := isDirectory $value.Source}}
-{{if $value.Writable}}
+{{if eq $value.Type "tmpfs"}}
+lxc.mount.entry = tmpfs {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} tmpfs {{formatMountLabel $value.Data ""}},create=dir 0 0
+{{else if $value.Writable}}
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,rw,create={{$createVal}} 0 0
 {{else}}
 lxc.mount.entry = {{$value.Source}} {{escapeFstabSpaces $ROOTFS}}/{{escapeFstabSpaces $value.Destination}} none rbind,ro,create={{$createVal}} 0 0
 {{end}}
 {{end}}
 
-{{if .ProcessConfig.Env}}
+{{if and .ProcessConfig.Env (not (isHostUTS .UTS))}}
 lxc.utsname = {{getHostname .ProcessConfig.Env}}
 {{end}}
 
@@ -106,6 +111,10 @@ lxc.aa_profile = unconfined
 {{end}}
 {{end}}
 
+{{if .CgroupParent}}
+lxc.cgroup.pattern = {{.CgroupParent}}/%n
+{{end}}
+
 # limits
 {{if .Resources}}
 {{if .Resources.Memory}}
@@ -115,12 +124,36 @@ lxc.cgroup.memory.soft_limit_in_bytes = {{.Resources.Memory}}
 lxc.cgroup.memory.memsw.limit_in_bytes = {{$memSwap}}
 {{end}}
 {{end}}
+{{if .Resources.OomKillDisable}}
+lxc.cgroup.memory.oom_control = 1
+{{end}}
 {{if .Resources.CpuShares}}
 lxc.cgroup.cpu.shares = {{.Resources.CpuShares}}
 {{end}}
+{{if .Resources.CpuPeriod}}
+lxc.cgroup.cpu.cfs_period_us = {{.Resources.CpuPeriod}}
+{{end}}
+{{if .Resources.CpuQuota}}
+lxc.cgroup.cpu.cfs_quota_us = {{.Resources.CpuQuota}}
+{{end}}
 {{if .Resources.Cpuset}}
 lxc.cgroup.cpuset.cpus = {{.Resources.Cpuset}}
 {{end}}
+{{if .Resources.BlkioWeight}}
+lxc.cgroup.blkio.weight = {{.Resources.BlkioWeight}}
+{{end}}
+{{range $throttle := .Resources.BlkioThrottleReadBpsDevice}}
+lxc.cgroup.blkio.throttle.read_bps_device = {{$throttle}}
+{{end}}
+{{range $throttle := .Resources.BlkioThrottleWriteBpsDevice}}
+lxc.cgroup.blkio.throttle.write_bps_device = {{$throttle}}
+{{end}}
+{{range $throttle := .Resources.BlkioThrottleReadIOpsDevice}}
+lxc.cgroup.blkio.throttle.read_iops_device = {{$throttle}}
+{{end}}
+{{range $throttle := .Resources.BlkioThrottleWriteIOpsDevice}}
+lxc.cgroup.blkio.throttle.write_iops_device = {{$throttle}}
+{{end}}
 {{end}}
 
 {{if .LxcConfig}}
@@ -171,6 +204,9 @@ func getMemorySwap(v *execdriver.Resources) int64 {
 	if v.MemorySwap < 0 {
 		return 0
 	}
+	if v.MemorySwap > 0 {
+		return v.MemorySwap
+	}
 	return v.Memory * 2
 }
 
@@ -195,6 +231,10 @@ func getHostname(env []string) string {
 	return ""
 }
 
+func isHostUTS(uts *execdriver.UTS) bool {
+	return uts != nil && uts.HostUTS
+}
+
 func init() {
 	var err error
 	funcMap := template.FuncMap{
@@ -204,6 +244,7 @@ func init() {
 		"isDirectory":       isDirectory,
 		"keepCapabilities":  keepCapabilities,
 		"getHostname":       getHostname,
+		"isHostUTS":         isHostUTS,
 	}
 	LxcTemplateCompiled, err = template.New("lxc").Funcs(funcMap).Parse(LxcTemplate)
 	if err != nil {
@@ -64,6 +64,8 @@ func TestLXCConfig(t *testing.T) {
 
 	grepFile(t, p,
 		fmt.Sprintf("lxc.cgroup.memory.memsw.limit_in_bytes = %d", mem*2))
+
+	grepFileWithReverse(t, p, "lxc.rootfs.options", true)
 }
 
 func TestCustomLxcConfig(t *testing.T) {
@@ -270,9 +272,10 @@ func TestCustomLxcConfigMisc(t *testing.T) {
 				Bridge:      "docker0",
 			},
 		},
-		ProcessConfig: processConfig,
-		CapAdd:        []string{"net_admin", "syslog"},
-		CapDrop:       []string{"kill", "mknod"},
+		ProcessConfig:  processConfig,
+		CapAdd:         []string{"net_admin", "syslog"},
+		CapDrop:        []string{"kill", "mknod"},
+		ReadonlyRootfs: true,
 	}
 
 	p, err := driver.generateLXCConfig(command)
@@ -299,4 +302,153 @@ func TestCustomLxcConfigMisc(t *testing.T) {
 	}
 	grepFileWithReverse(t, p, fmt.Sprintf("lxc.cap.keep = kill"), true)
 	grepFileWithReverse(t, p, fmt.Sprintf("lxc.cap.keep = mknod"), true)
+
+	// read only rootfs
+	grepFile(t, p, "lxc.rootfs.options = ro")
+}
+
+func TestLXCConfigResources(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigResources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+	driver, err := NewDriver(root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Resources: &execdriver.Resources{
+			Memory:         33554432,
+			MemorySwap:     67108864,
+			CpuPeriod:      50000,
+			CpuQuota:       25000,
+			BlkioWeight:    300,
+			OomKillDisable: true,
+			BlkioThrottleReadBpsDevice: []*execdriver.ThrottleDevice{
+				{Major: 8, Minor: 0, Rate: 1048576},
+				{Major: 8, Minor: 16, Rate: 2097152},
+			},
+			BlkioThrottleWriteIOpsDevice: []*execdriver.ThrottleDevice{
+				{Major: 8, Minor: 0, Rate: 100},
+			},
+		},
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		AllowedDevices: make([]*devices.Device, 0),
+		ProcessConfig:  execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.cgroup.memory.memsw.limit_in_bytes = 67108864")
+	grepFile(t, p, "lxc.cgroup.memory.oom_control = 1")
+	grepFile(t, p, "lxc.cgroup.cpu.cfs_period_us = 50000")
+	grepFile(t, p, "lxc.cgroup.cpu.cfs_quota_us = 25000")
+	grepFile(t, p, "lxc.cgroup.blkio.weight = 300")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.read_bps_device = 8:0 1048576")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.read_bps_device = 8:16 2097152")
+	grepFile(t, p, "lxc.cgroup.blkio.throttle.write_iops_device = 8:0 100")
+	grepFileWithReverse(t, p, "lxc.cgroup.blkio.throttle.write_bps_device", true)
+}
+
+func TestLXCConfigHostUTS(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigHostUTS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+	driver, err := NewDriver(root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	processConfig := execdriver.ProcessConfig{}
+	processConfig.Env = []string{"HOSTNAME=testhost"}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		UTS: &execdriver.UTS{
+			HostUTS: true,
+		},
+		AllowedDevices: make([]*devices.Device, 0),
+		ProcessConfig:  processConfig,
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.utsname", true)
+}
+
+func TestLXCConfigCgroupParent(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigCgroupParent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+	driver, err := NewDriver(root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		AllowedDevices: make([]*devices.Device, 0),
+		ProcessConfig:  execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFileWithReverse(t, p, "lxc.cgroup.pattern", true)
+
+	command.CgroupParent = "/teams/a"
+	p, err = driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.cgroup.pattern = /teams/a/%n")
+}
+
+func TestLXCConfigTmpfs(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLXCConfigTmpfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+	driver, err := NewDriver(root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu:       1500,
+			Interface: nil,
+		},
+		Mounts: []execdriver.Mount{
+			{Source: "tmpfs", Destination: "/run", Writable: true, Type: "tmpfs", Data: "noexec,nosuid,nodev,size=64m"},
+		},
+		AllowedDevices: make([]*devices.Device, 0),
+		ProcessConfig:  execdriver.ProcessConfig{},
+	}
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.mount.entry = tmpfs "+command.Rootfs+"//run tmpfs noexec,nosuid,nodev,size=64m,create=dir 0 0")
 }
@@ -7,11 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/daemon/execdriver/native/template"
 	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/apparmor"
+	"github.com/docker/libcontainer/cgroups/systemd"
 	"github.com/docker/libcontainer/devices"
 	"github.com/docker/libcontainer/mount"
 	"github.com/docker/libcontainer/security/capabilities"
@@ -29,17 +31,36 @@ func (d *driver) createContainer(c *execdriver.Command) (*libcontainer.Config, e
 	container.Env = c.ProcessConfig.Env
 	container.Cgroups.Name = c.ID
 	container.Cgroups.AllowedDevices = c.AllowedDevices
+	if c.CgroupParent != "" {
+		// systemd places the containers in slices rather than directories
+		if systemd.UseSystemd() && (!strings.HasSuffix(c.CgroupParent, ".slice") || strings.Contains(c.CgroupParent, "/")) {
+			return nil, fmt.Errorf("cgroup parent %q is not a systemd slice, such as team-a.slice", c.CgroupParent)
+		}
+		container.Cgroups.Parent = c.CgroupParent
+	}
 	container.MountConfig.DeviceNodes = c.AutoCreatedDevices
 	container.RootFs = c.Rootfs
+	container.MountConfig.ReadonlyFs = c.ReadonlyRootfs
 
 	// check to see if we are running in ramdisk to disable pivot root
 	container.MountConfig.NoPivotRoot = os.Getenv("DOCKER_RAMDISK") != ""
 	container.RestrictSys = true
+	container.Sysctl = c.Sysctls
 
 	if err := d.createIpc(container, c); err != nil {
 		return nil, err
 	}
 
+	if err := d.createPid(container, c); err != nil {
+		return nil, err
+	}
+
+	d.createUTS(container, c)
+
+	if err := d.createUser(container, c); err != nil {
+		return nil, err
+	}
+
 	if err := d.createNetwork(container, c); err != nil {
 		return nil, err
 	}
@@ -58,10 +79,16 @@ func (d *driver) createContainer(c *execdriver.Command) (*libcontainer.Config, e
 		container.AppArmorProfile = c.AppArmorProfile
 	}
 
+	if err := d.setupSeccomp(container, c); err != nil {
+		return nil, err
+	}
+
 	if err := d.setupCgroups(container, c); err != nil {
 		return nil, err
 	}
 
+	d.setupRlimits(container, c)
+
 	if err := d.setupMounts(container, c); err != nil {
 		return nil, err
 	}
@@ -150,6 +177,54 @@ func (d *driver) createIpc(container *libcontainer.Config, c *execdriver.Command
 	return nil
 }
 
+func (d *driver) createPid(container *libcontainer.Config, c *execdriver.Command) error {
+	if c.Pid.HostPid {
+		container.Namespaces["NEWPID"] = false
+		return nil
+	}
+
+	if c.Pid.ContainerID != "" {
+		d.Lock()
+		active := d.activeContainers[c.Pid.ContainerID]
+		d.Unlock()
+
+		if active == nil || active.cmd.Process == nil {
+			return fmt.Errorf("%s is not a valid running container to join", c.Pid.ContainerID)
+		}
+		cmd := active.cmd
+
+		container.Namespaces["NEWPID"] = false
+		container.PidNsPath = filepath.Join("/proc", fmt.Sprint(cmd.Process.Pid), "ns", "pid")
+	}
+
+	return nil
+}
+
+func (d *driver) createUTS(container *libcontainer.Config, c *execdriver.Command) {
+	if c.UTS.HostUTS {
+		container.Namespaces["NEWUTS"] = false
+		// the hostname of the host must not be changed
+		container.Hostname = ""
+	}
+}
+
+func (d *driver) createUser(container *libcontainer.Config, c *execdriver.Command) error {
+	if c.UIDMapping == nil && c.GIDMapping == nil {
+		return nil
+	}
+	if !userNamespaceSupported {
+		return fmt.Errorf("user namespaces are not supported by this build of Docker, it requires Go 1.4 or later")
+	}
+	container.Namespaces["NEWUSER"] = true
+	for _, m := range c.UIDMapping {
+		container.UidMappings = append(container.UidMappings, libcontainer.IDMap{ContainerID: m.ContainerID, HostID: m.HostID, Size: m.Size})
+	}
+	for _, m := range c.GIDMapping {
+		container.GidMappings = append(container.GidMappings, libcontainer.IDMap{ContainerID: m.ContainerID, HostID: m.HostID, Size: m.Size})
+	}
+	return nil
+}
+
 func (d *driver) setPrivileged(container *libcontainer.Config) (err error) {
 	container.Capabilities = capabilities.GetAllCapabilities()
 	container.Cgroups.AllowAllDevices = true
@@ -181,13 +256,51 @@ func (d *driver) setupCgroups(container *libcontainer.Config, c *execdriver.Comm
 		container.Cgroups.MemoryReservation = c.Resources.Memory
 		container.Cgroups.MemorySwap = c.Resources.MemorySwap
 		container.Cgroups.CpusetCpus = c.Resources.Cpuset
+		container.Cgroups.CpuPeriod = c.Resources.CpuPeriod
+		container.Cgroups.CpuQuota = c.Resources.CpuQuota
+		container.Cgroups.BlkioWeight = c.Resources.BlkioWeight
+		container.Cgroups.BlkioThrottleReadBpsDevice = joinThrottleDevices(c.Resources.BlkioThrottleReadBpsDevice)
+		container.Cgroups.BlkioThrottleWriteBpsDevice = joinThrottleDevices(c.Resources.BlkioThrottleWriteBpsDevice)
+		container.Cgroups.BlkioThrottleReadIOpsDevice = joinThrottleDevices(c.Resources.BlkioThrottleReadIOpsDevice)
+		container.Cgroups.BlkioThrottleWriteIOpsDevice = joinThrottleDevices(c.Resources.BlkioThrottleWriteIOpsDevice)
+		container.Cgroups.OomKillDisable = c.Resources.OomKillDisable
 	}
 
 	return nil
 }
 
+func joinThrottleDevices(throttles []*execdriver.ThrottleDevice) string {
+	entries := make([]string, len(throttles))
+	for i, throttle := range throttles {
+		entries[i] = throttle.String()
+	}
+	return strings.Join(entries, "\n")
+}
+
+func (d *driver) setupRlimits(container *libcontainer.Config, c *execdriver.Command) {
+	if c.Resources == nil {
+		return
+	}
+
+	for _, rlimit := range c.Resources.Rlimits {
+		container.Rlimits = append(container.Rlimits, libcontainer.Rlimit{
+			Type: rlimit.Type,
+			Hard: rlimit.Hard,
+			Soft: rlimit.Soft,
+		})
+	}
+}
+
 func (d *driver) setupMounts(container *libcontainer.Config, c *execdriver.Command) error {
 	for _, m := range c.Mounts {
+		if m.Type == "tmpfs" {
+			container.MountConfig.Mounts = append(container.MountConfig.Mounts, &mount.Mount{
+				Type:        "tmpfs",
+				Destination: m.Destination,
+				Data:        m.Data,
+			})
+			continue
+		}
 		container.MountConfig.Mounts = append(container.MountConfig.Mounts, &mount.Mount{
 			Type:        "bind",
 			Source:      m.Source,
@@ -40,11 +40,12 @@ type activeContainer struct {
 type driver struct {
 	root             string
 	initPath         string
+	liveRestore      bool // run the containers under a shim so that they survive the daemon
 	activeContainers map[string]*activeContainer
 	sync.Mutex
 }
 
-func NewDriver(root, initPath string) (*driver, error) {
+func NewDriver(root, initPath string, liveRestore bool) (*driver, error) {
 	if err := os.MkdirAll(root, 0700); err != nil {
 		return nil, err
 	}
@@ -57,6 +58,7 @@ func NewDriver(root, initPath string) (*driver, error) {
 	return &driver{
 		root:             root,
 		initPath:         initPath,
+		liveRestore:      liveRestore,
 		activeContainers: make(map[string]*activeContainer),
 	}, nil
 }
@@ -77,6 +79,10 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		return execdriver.ExitStatus{-1, false}, err
 	}
 
+	if d.liveRestore {
+		return d.runShim(c, container, pipes, startCallback)
+	}
+
 	var term execdriver.Terminal
 
 	if c.ProcessConfig.Tty {
@@ -115,25 +121,7 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 
 	go func() {
 		exitCode, err := namespaces.Exec(container, c.ProcessConfig.Stdin, c.ProcessConfig.Stdout, c.ProcessConfig.Stderr, c.ProcessConfig.Console, dataPath, args, func(container *libcontainer.Config, console, dataPath, init string, child *os.File, args []string) *exec.Cmd {
-			c.ProcessConfig.Path = d.initPath
-			c.ProcessConfig.Args = append([]string{
-				DriverName,
-				"-console", console,
-				"-pipe", "3",
-				"-root", filepath.Join(d.root, c.ID),
-				"--",
-			}, args...)
-
-			// set this to nil so that when we set the clone flags anything else is reset
-			c.ProcessConfig.SysProcAttr = &syscall.SysProcAttr{
-				Cloneflags: uintptr(namespaces.GetNamespaceFlags(container.Namespaces)),
-			}
-			c.ProcessConfig.ExtraFiles = []*os.File{child}
-
-			c.ProcessConfig.Env = container.Env
-			c.ProcessConfig.Dir = container.RootFs
-
-			return &c.ProcessConfig.Cmd
+			return initCommand(&c.ProcessConfig.Cmd, container, d.initPath, console, filepath.Join(d.root, c.ID), child, args)
 		}, func() {
 			close(waitForStart)
 			if startCallback != nil {
@@ -164,6 +152,32 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	return execdriver.ExitStatus{execOutput.exitCode, oomKill}, execOutput.err
 }
 
+// initCommand sets up cmd to run the container's init in its namespaces
+func initCommand(cmd *exec.Cmd, container *libcontainer.Config, initPath, console, dataPath string, child *os.File, args []string) *exec.Cmd {
+	cmd.Path = initPath
+	cmd.Args = append([]string{
+		DriverName,
+		"-console", console,
+		"-pipe", "3",
+		"-root", dataPath,
+		"--",
+	}, args...)
+
+	// set this to nil so that when we set the clone flags anything else is reset
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: uintptr(namespaces.GetNamespaceFlags(container.Namespaces)),
+	}
+	if container.Namespaces["NEWUSER"] {
+		setupUserNamespace(cmd.SysProcAttr, container)
+	}
+	cmd.ExtraFiles = []*os.File{child}
+
+	cmd.Env = container.Env
+	cmd.Dir = container.RootFs
+
+	return cmd
+}
+
 func (d *driver) Kill(p *execdriver.Command, sig int) error {
 	return syscall.Kill(p.ProcessConfig.Process.Pid, syscall.Signal(sig))
 }
@@ -263,10 +277,25 @@ func (d *driver) writeContainerFile(container *libcontainer.Config, id string) e
 	return ioutil.WriteFile(filepath.Join(d.root, id, "container.json"), data, 0655)
 }
 
+func (d *driver) readContainerFile(id string) (*libcontainer.Config, error) {
+	f, err := os.Open(filepath.Join(d.root, id, "container.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var container *libcontainer.Config
+	if err := json.NewDecoder(f).Decode(&container); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
 func (d *driver) cleanContainer(id string) error {
 	d.Lock()
 	delete(d.activeContainers, id)
 	d.Unlock()
+	os.Remove(filepath.Join(d.root, id, shimExitFile))
 	return os.RemoveAll(filepath.Join(d.root, id, "container.json"))
 }
 
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"syscall"
 
 	"github.com/docker/docker/daemon/execdriver"
 	"github.com/docker/docker/pkg/reexec"
@@ -33,6 +34,15 @@ func nsenterExec() {
 		log.Fatalf("docker-exec: unable to receive config from sync pipe: %s", err)
 	}
 
+	// setns does not go through the container init, so the rlimits of the
+	// container have to be applied to the exec'd process here
+	for _, rlimit := range config.Rlimits {
+		l := &syscall.Rlimit{Max: rlimit.Hard, Cur: rlimit.Soft}
+		if err := syscall.Setrlimit(rlimit.Type, l); err != nil {
+			log.Fatalf("docker-exec: unable to set rlimit type %v: %s", rlimit.Type, err)
+		}
+	}
+
 	if err := namespaces.FinalizeSetns(config, userArgs); err != nil {
 		log.Fatalf("docker-exec: failed to exec: %s", err)
 	}
@@ -0,0 +1,105 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/security/seccomp"
+)
+
+// defaultSeccompProfile denies the syscalls which give access to the
+// kernel, the host or other processes beyond what a container needs
+var defaultSeccompProfile = &seccomp.Seccomp{
+	DefaultAction: seccomp.ActAllow,
+	Syscalls: []*seccomp.Syscall{
+		// kernel modules, keyring and kernel replacement
+		{Name: "create_module", Action: seccomp.ActErrno},
+		{Name: "init_module", Action: seccomp.ActErrno},
+		{Name: "finit_module", Action: seccomp.ActErrno},
+		{Name: "delete_module", Action: seccomp.ActErrno},
+		{Name: "get_kernel_syms", Action: seccomp.ActErrno},
+		{Name: "query_module", Action: seccomp.ActErrno},
+		{Name: "kexec_load", Action: seccomp.ActErrno},
+		{Name: "kexec_file_load", Action: seccomp.ActErrno},
+		{Name: "add_key", Action: seccomp.ActErrno},
+		{Name: "keyctl", Action: seccomp.ActErrno},
+		{Name: "request_key", Action: seccomp.ActErrno},
+		{Name: "bpf", Action: seccomp.ActErrno},
+		{Name: "perf_event_open", Action: seccomp.ActErrno},
+		{Name: "lookup_dcookie", Action: seccomp.ActErrno},
+		{Name: "uselib", Action: seccomp.ActErrno},
+		{Name: "userfaultfd", Action: seccomp.ActErrno},
+
+		// host wide settings
+		{Name: "reboot", Action: seccomp.ActErrno},
+		{Name: "swapon", Action: seccomp.ActErrno},
+		{Name: "swapoff", Action: seccomp.ActErrno},
+		{Name: "acct", Action: seccomp.ActErrno},
+		{Name: "quotactl", Action: seccomp.ActErrno},
+		{Name: "nfsservctl", Action: seccomp.ActErrno},
+		{Name: "_sysctl", Action: seccomp.ActErrno},
+		{Name: "sysfs", Action: seccomp.ActErrno},
+		{Name: "ustat", Action: seccomp.ActErrno},
+		{Name: "settimeofday", Action: seccomp.ActErrno},
+		{Name: "stime", Action: seccomp.ActErrno},
+		{Name: "clock_settime", Action: seccomp.ActErrno},
+		{Name: "clock_adjtime", Action: seccomp.ActErrno},
+		{Name: "adjtimex", Action: seccomp.ActErrno},
+		{Name: "ioperm", Action: seccomp.ActErrno},
+		{Name: "iopl", Action: seccomp.ActErrno},
+		{Name: "vm86", Action: seccomp.ActErrno},
+		{Name: "vm86old", Action: seccomp.ActErrno},
+
+		// mounts and namespaces
+		{Name: "mount", Action: seccomp.ActErrno},
+		{Name: "umount", Action: seccomp.ActErrno},
+		{Name: "umount2", Action: seccomp.ActErrno},
+		{Name: "pivot_root", Action: seccomp.ActErrno},
+		{Name: "setns", Action: seccomp.ActErrno},
+		{Name: "unshare", Action: seccomp.ActErrno},
+		{
+			Name:   "clone",
+			Action: seccomp.ActErrno,
+			Args: []*seccomp.Arg{
+				{Index: 0, Value: 0x10000000, ValueTwo: 0x10000000, Op: seccomp.OpMaskedEqual}, // CLONE_NEWUSER
+			},
+		},
+		{Name: "name_to_handle_at", Action: seccomp.ActErrno},
+		{Name: "open_by_handle_at", Action: seccomp.ActErrno},
+
+		// other processes and NUMA memory policies
+		{Name: "ptrace", Action: seccomp.ActErrno},
+		{Name: "process_vm_readv", Action: seccomp.ActErrno},
+		{Name: "process_vm_writev", Action: seccomp.ActErrno},
+		{Name: "kcmp", Action: seccomp.ActErrno},
+		{Name: "get_mempolicy", Action: seccomp.ActErrno},
+		{Name: "set_mempolicy", Action: seccomp.ActErrno},
+		{Name: "mbind", Action: seccomp.ActErrno},
+		{Name: "move_pages", Action: seccomp.ActErrno},
+	},
+}
+
+// setupSeccomp sets the syscall filter of the container.  Privileged
+// containers are not filtered unless a profile is given.
+func (d *driver) setupSeccomp(container *libcontainer.Config, c *execdriver.Command) error {
+	switch c.SeccompProfile {
+	case "unconfined":
+		return nil
+	case "":
+		if !c.ProcessConfig.Privileged {
+			container.Seccomp = defaultSeccompProfile
+		}
+		return nil
+	}
+
+	profile := &seccomp.Seccomp{}
+	if err := json.Unmarshal([]byte(c.SeccompProfile), profile); err != nil {
+		return fmt.Errorf("decoding seccomp profile failed: %s", err)
+	}
+	container.Seccomp = profile
+	return nil
+}
@@ -0,0 +1,47 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/security/seccomp"
+)
+
+func TestDefaultSeccompProfile(t *testing.T) {
+	if err := seccomp.Validate(defaultSeccompProfile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupSeccomp(t *testing.T) {
+	d := &driver{}
+	for _, c := range []struct {
+		profile    string
+		privileged bool
+		expected   *seccomp.Seccomp
+	}{
+		{"", false, defaultSeccompProfile},
+		{"", true, nil},
+		{"unconfined", false, nil},
+		{`{"defaultAction": "SCMP_ACT_ERRNO"}`, true, &seccomp.Seccomp{DefaultAction: seccomp.ActErrno}},
+	} {
+		container := &libcontainer.Config{}
+		command := &execdriver.Command{
+			SeccompProfile: c.profile,
+			ProcessConfig:  execdriver.ProcessConfig{Privileged: c.privileged},
+		}
+		if err := d.setupSeccomp(container, command); err != nil {
+			t.Fatal(err)
+		}
+		if (container.Seccomp == nil) != (c.expected == nil) || (container.Seccomp != nil && container.Seccomp.DefaultAction != c.expected.DefaultAction) {
+			t.Fatalf("profile %q, privileged %v: expected %v, got %v", c.profile, c.privileged, c.expected, container.Seccomp)
+		}
+	}
+
+	if err := d.setupSeccomp(&libcontainer.Config{}, &execdriver.Command{SeccompProfile: "notjson"}); err == nil {
+		t.Fatal("expected an error for an invalid profile")
+	}
+}
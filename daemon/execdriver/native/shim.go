@@ -0,0 +1,550 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups/fs"
+	consolepkg "github.com/docker/libcontainer/console"
+	"github.com/docker/libcontainer/namespaces"
+)
+
+// The shim is a small supervisor started by dockerinit for each container
+// when live restore is enabled.  It runs the container in place of the
+// daemon, keeps its stdio and records its exit status, so that a daemon
+// which restarts can re-attach to the containers which kept running.
+//
+// The daemon talks to the shim over unix sockets: the initial connections
+// are passed as file descriptors when the shim is started, a daemon which
+// re-attaches dials the shim's abstract socket and names the stream it
+// wants on the first line of each connection.
+const (
+	shimCommandName = "native-shim"
+
+	// shimExitFile is where the shim records the exit status of the container
+	// in case no daemon is attached when it exits
+	shimExitFile = "exit.json"
+
+	// shimOutputBufferSize is the amount of the container's output kept by
+	// the shim while no daemon reads it
+	shimOutputBufferSize = 1024 * 1024
+)
+
+// file descriptors of the initial connections to the daemon
+const (
+	shimControlFd = 3 + iota
+	shimStdinFd
+	shimStdoutFd
+	shimStderrFd
+)
+
+// shimMessage is sent by the shim on the control connection once the
+// container started and once it exited.
+type shimMessage struct {
+	Pid    int                    `json:",omitempty"`
+	Status *execdriver.ExitStatus `json:",omitempty"`
+	Error  string                 `json:",omitempty"`
+}
+
+// shimRequest is sent by the daemon on the control connection.
+type shimRequest struct {
+	Action string // "resize" or "close-stdin"
+	Height int    `json:",omitempty"`
+	Width  int    `json:",omitempty"`
+}
+
+func init() {
+	reexec.Register(shimCommandName, shimMain)
+}
+
+// shimAddress returns the address of the abstract unix socket the shim of a
+// container listens on.
+func shimAddress(id string) string {
+	return "@docker-shim-" + id
+}
+
+func shimMain() {
+	var (
+		id   = flag.String("id", "", "id of the container")
+		root = flag.String("root", ".", "root path for configuration files")
+		tty  = flag.Bool("tty", false, "allocate a tty for the container")
+	)
+
+	flag.Parse()
+
+	// the shim must outlive the daemon along with the session it was started in
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGHUP, syscall.SIGINT, syscall.SIGPIPE)
+
+	s := &shim{
+		id:     *id,
+		root:   *root,
+		tty:    *tty,
+		stdout: newShimOutput(),
+		stderr: newShimOutput(),
+	}
+	os.Exit(s.run(flag.Args()))
+}
+
+type shim struct {
+	id   string
+	root string
+	tty  bool
+
+	stdout *shimOutput
+	stderr *shimOutput
+
+	mu      sync.Mutex
+	sendMu  sync.Mutex
+	stdin   io.WriteCloser // container's stdin, or the pty master with a tty
+	master  *os.File
+	control net.Conn
+	pid     int
+	status  *execdriver.ExitStatus
+}
+
+func (s *shim) run(args []string) int {
+	s.attachControl(fileConn(shimControlFd))
+
+	container, err := s.loadConfig()
+	if err != nil {
+		return s.fail(err)
+	}
+
+	l, err := net.Listen("unix", shimAddress(s.id))
+	if err != nil {
+		return s.fail(err)
+	}
+	defer l.Close()
+
+	var (
+		console string
+		stdin   io.Reader
+		stdout  io.Writer
+		stderr  io.Writer
+		// the ends of the pipes given to the container
+		childFiles []*os.File
+	)
+	if s.tty {
+		master, path, err := consolepkg.CreateMasterAndConsole()
+		if err != nil {
+			return s.fail(err)
+		}
+		s.mu.Lock()
+		s.master, s.stdin = master, master
+		s.mu.Unlock()
+		console = path
+		go s.stdout.copyFrom(master)
+		// stderr goes to the tty as well
+		s.stderr.close()
+	} else {
+		stdinR, stdinW, err := os.Pipe()
+		if err != nil {
+			return s.fail(err)
+		}
+		stdoutR, stdoutW, err := os.Pipe()
+		if err != nil {
+			return s.fail(err)
+		}
+		stderrR, stderrW, err := os.Pipe()
+		if err != nil {
+			return s.fail(err)
+		}
+		s.mu.Lock()
+		s.stdin = stdinW
+		s.mu.Unlock()
+		stdin, stdout, stderr = stdinR, stdoutW, stderrW
+		childFiles = []*os.File{stdinR, stdoutW, stderrW}
+		go s.stdout.copyFrom(stdoutR)
+		go s.stderr.copyFrom(stderrR)
+	}
+	s.attachStdin(fileConn(shimStdinFd))
+	s.stdout.attach(fileConn(shimStdoutFd))
+	s.stderr.attach(fileConn(shimStderrFd))
+
+	go s.serve(l)
+
+	var (
+		cmd      *exec.Cmd
+		exitCode int
+		execErr  error
+		started  = make(chan struct{})
+		exited   = make(chan struct{})
+	)
+	go func() {
+		exitCode, execErr = namespaces.Exec(container, stdin, stdout, stderr, console, s.root, args, func(container *libcontainer.Config, console, dataPath, init string, child *os.File, args []string) *exec.Cmd {
+			// the shim is dockerinit itself
+			cmd = initCommand(&exec.Cmd{}, container, "/proc/self/exe", console, dataPath, child, args)
+			return cmd
+		}, func() {
+			close(started)
+		})
+		close(exited)
+	}()
+
+	select {
+	case <-started:
+	case <-exited:
+		if execErr == nil {
+			execErr = fmt.Errorf("container exited before it started")
+		}
+		return s.fail(execErr)
+	}
+	for _, f := range childFiles {
+		f.Close()
+	}
+
+	s.mu.Lock()
+	s.pid = cmd.Process.Pid
+	s.mu.Unlock()
+	s.send(&shimMessage{Pid: cmd.Process.Pid})
+
+	oomKill := false
+	if oomKillNotification, err := fs.NotifyOnOOM(container.Cgroups); err == nil {
+		_, oomKill = <-oomKillNotification
+	}
+	<-exited
+
+	if !s.tty {
+		s.stdin.Close()
+	}
+	// deliver what is left of the output to the daemon, if one is attached
+	s.stdout.wait()
+	s.stderr.wait()
+
+	status := &execdriver.ExitStatus{ExitCode: exitCode, OOMKilled: oomKill}
+	if err := s.writeExitStatus(status); err != nil {
+		execErr = err
+	}
+
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	msg := &shimMessage{Status: status}
+	if execErr != nil {
+		msg.Error = execErr.Error()
+	}
+	s.send(msg)
+
+	return 0
+}
+
+func (s *shim) loadConfig() (*libcontainer.Config, error) {
+	f, err := os.Open(filepath.Join(s.root, "container.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var container *libcontainer.Config
+	if err := json.NewDecoder(f).Decode(&container); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+func (s *shim) writeExitStatus(status *execdriver.ExitStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.root, shimExitFile+".tmp")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.root, shimExitFile))
+}
+
+// fail reports an error starting the container to the daemon.
+func (s *shim) fail(err error) int {
+	s.send(&shimMessage{Error: err.Error()})
+	return 1
+}
+
+func (s *shim) send(msg *shimMessage) {
+	s.mu.Lock()
+	conn := s.control
+	s.mu.Unlock()
+	if conn == nil {
+		return
+	}
+
+	s.sendMu.Lock()
+	json.NewEncoder(conn).Encode(msg)
+	s.sendMu.Unlock()
+}
+
+func (s *shim) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *shim) handle(conn net.Conn) {
+	if err := checkShimPeer(conn); err != nil {
+		conn.Close()
+		return
+	}
+	stream, err := readShimLine(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	switch stream {
+	case "control":
+		s.attachControl(conn)
+	case "stdin":
+		s.attachStdin(conn)
+	case "stdout":
+		s.stdout.attach(conn)
+	case "stderr":
+		s.stderr.attach(conn)
+	default:
+		conn.Close()
+	}
+}
+
+func (s *shim) attachControl(conn net.Conn) {
+	if conn == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.control != nil {
+		s.control.Close()
+	}
+	s.control = conn
+	pid, status := s.pid, s.status
+	s.mu.Unlock()
+
+	if pid != 0 {
+		s.send(&shimMessage{Pid: pid})
+	}
+	if status != nil {
+		s.send(&shimMessage{Status: status})
+	}
+
+	go func() {
+		dec := json.NewDecoder(conn)
+		for {
+			var req shimRequest
+			if err := dec.Decode(&req); err != nil {
+				return
+			}
+			s.mu.Lock()
+			master, stdin := s.master, s.stdin
+			s.mu.Unlock()
+			switch req.Action {
+			case "resize":
+				if master != nil {
+					term.SetWinsize(master.Fd(), &term.Winsize{Height: uint16(req.Height), Width: uint16(req.Width)})
+				}
+			case "close-stdin":
+				if !s.tty && stdin != nil {
+					stdin.Close()
+				}
+			}
+		}
+	}()
+}
+
+func (s *shim) attachStdin(conn net.Conn) {
+	if conn == nil {
+		return
+	}
+	// the end of a connection only means the daemon went away, stdin is
+	// closed on request
+	go func() {
+		io.Copy(s.stdin, conn)
+		conn.Close()
+	}()
+}
+
+// fileConn returns the connection to the daemon inherited as fd, if any.
+func fileConn(fd int) net.Conn {
+	f := os.NewFile(uintptr(fd), "daemon")
+	defer f.Close()
+	conn, err := net.FileConn(f)
+	if err != nil {
+		return nil
+	}
+	return conn
+}
+
+// checkShimPeer makes sure that only root or the user running the shim can
+// connect to the container's stdio.
+func checkShimPeer(conn net.Conn) error {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("not a unix connection")
+	}
+	f, err := uc.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cred, err := syscall.GetsockoptUcred(int(f.Fd()), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return err
+	}
+	if cred.Uid != 0 && int(cred.Uid) != os.Getuid() {
+		return fmt.Errorf("permission denied for uid %d", cred.Uid)
+	}
+	return nil
+}
+
+// readShimLine reads a line one byte at a time so that nothing which
+// follows it on the connection is consumed.
+func readShimLine(r io.Reader) (string, error) {
+	var (
+		line []byte
+		b    = make([]byte, 1)
+	)
+	for len(line) < 64 {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		if b[0] == '\n' {
+			return string(line), nil
+		}
+		line = append(line, b[0])
+	}
+	return "", fmt.Errorf("line too long")
+}
+
+// shimOutput buffers an output stream of the container until the daemon
+// reads it.  While no daemon is attached only the most recent output is
+// kept, so that the container never blocks on a daemon which went away.
+type shimOutput struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  []byte
+	conn net.Conn
+	eof  bool
+	done chan struct{}
+}
+
+func newShimOutput() *shimOutput {
+	o := &shimOutput{done: make(chan struct{})}
+	o.cond = sync.NewCond(&o.mu)
+	go o.run()
+	return o
+}
+
+func (o *shimOutput) copyFrom(r io.Reader) {
+	data := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(data)
+		if n > 0 {
+			o.mu.Lock()
+			for o.conn != nil && len(o.buf) >= shimOutputBufferSize {
+				o.cond.Wait()
+			}
+			o.buf = append(o.buf, data[:n]...)
+			if o.conn == nil {
+				o.truncate()
+			}
+			o.cond.Broadcast()
+			o.mu.Unlock()
+		}
+		if err != nil {
+			// the pty master returns EIO once the container is gone
+			o.close()
+			return
+		}
+	}
+}
+
+// close marks the end of the stream.
+func (o *shimOutput) close() {
+	o.mu.Lock()
+	o.eof = true
+	o.cond.Broadcast()
+	o.mu.Unlock()
+}
+
+// truncate must be called with o.mu held.
+func (o *shimOutput) truncate() {
+	if over := len(o.buf) - shimOutputBufferSize; over > 0 {
+		o.buf = o.buf[over:]
+	}
+}
+
+func (o *shimOutput) run() {
+	defer close(o.done)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for {
+		for !o.eof && (o.conn == nil || len(o.buf) == 0) {
+			o.cond.Wait()
+		}
+		if o.conn == nil {
+			// nobody to deliver the end of the output to
+			return
+		}
+		if len(o.buf) == 0 {
+			o.conn.Close()
+			o.conn = nil
+			return
+		}
+
+		conn, data := o.conn, o.buf
+		o.buf = nil
+		o.mu.Unlock()
+		n, err := conn.Write(data)
+		o.mu.Lock()
+		if err != nil {
+			// the daemon went away, keep what it did not read for the next one
+			o.buf = append(data[n:], o.buf...)
+			o.truncate()
+			if o.conn == conn {
+				o.conn = nil
+			}
+			conn.Close()
+		}
+		o.cond.Broadcast()
+	}
+}
+
+func (o *shimOutput) attach(conn net.Conn) {
+	if conn == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	select {
+	case <-o.done:
+		conn.Close()
+		return
+	default:
+	}
+	if o.conn != nil {
+		o.conn.Close()
+	}
+	o.conn = conn
+	o.cond.Broadcast()
+}
+
+func (o *shimOutput) wait() {
+	<-o.done
+}
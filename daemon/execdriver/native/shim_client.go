@@ -0,0 +1,277 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+)
+
+// shimClient is the daemon's side of the connections to the shim of a
+// container.  It is the container's terminal as well, resizing the tty
+// through the shim.
+type shimClient struct {
+	control net.Conn
+	dec     *json.Decoder
+	stdin   net.Conn
+	stdout  net.Conn
+	stderr  net.Conn
+
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+// runShim starts the container under a shim and blocks until it exits.
+func (d *driver) runShim(c *execdriver.Command, container *libcontainer.Config, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	d.Lock()
+	d.activeContainers[c.ID] = &activeContainer{
+		container: container,
+		cmd:       &c.ProcessConfig.Cmd,
+	}
+	d.Unlock()
+
+	dataPath := filepath.Join(d.root, c.ID)
+	if err := d.createContainerRoot(c.ID); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	defer d.cleanContainer(c.ID)
+
+	if err := d.writeContainerFile(container, c.ID); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	os.Remove(filepath.Join(dataPath, shimExitFile))
+
+	client, files, err := newShimPipes()
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	cmd := &exec.Cmd{
+		Path: d.initPath,
+		Args: append([]string{
+			shimCommandName,
+			"-id", c.ID,
+			"-root", dataPath,
+			"-tty=" + strconv.FormatBool(c.ProcessConfig.Tty),
+			"--",
+			c.ProcessConfig.Entrypoint,
+		}, c.ProcessConfig.Arguments...),
+		ExtraFiles: files,
+		// start the shim in its own session so that it is not affected by
+		// the daemon going away
+		SysProcAttr: &syscall.SysProcAttr{Setsid: true},
+	}
+	err = cmd.Start()
+	for _, f := range files {
+		f.Close()
+	}
+	if err != nil {
+		client.Close()
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	// reap the shim if it exits while this daemon is running
+	go cmd.Wait()
+
+	return d.attachShim(c, client, pipes, startCallback)
+}
+
+func (d *driver) Reattach(c *execdriver.Command, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	dataPath := filepath.Join(d.root, c.ID)
+
+	container, err := d.readContainerFile(c.ID)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	d.Lock()
+	d.activeContainers[c.ID] = &activeContainer{
+		container: container,
+		cmd:       &c.ProcessConfig.Cmd,
+	}
+	d.Unlock()
+	defer d.cleanContainer(c.ID)
+
+	client, err := dialShim(c.ID, c.ProcessConfig.Tty)
+	if err != nil {
+		// the container may have exited while the daemon was down
+		if status, err := readShimExitStatus(dataPath); err == nil {
+			return *status, nil
+		}
+		// or it was not started under a shim, in which case nothing
+		// supervises it anymore
+		if c.ProcessConfig.Process != nil {
+			d.Terminate(c)
+		}
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("Cannot re-attach to container %s: %s", c.ID, err)
+	}
+
+	return d.attachShim(c, client, pipes, startCallback)
+}
+
+// attachShim connects the container's pipes to the shim and blocks until
+// the container exits.
+func (d *driver) attachShim(c *execdriver.Command, client *shimClient, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	defer client.Close()
+
+	msg, err := client.receive()
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("shim exited before the container started: %s", err)
+	}
+	if msg.Error != "" {
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("%s", msg.Error)
+	}
+
+	var wg sync.WaitGroup
+	copyOutput := func(dst io.Writer, src net.Conn) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			io.Copy(dst, src)
+		}()
+	}
+	copyOutput(pipes.Stdout, client.stdout)
+	if !c.ProcessConfig.Tty {
+		copyOutput(pipes.Stderr, client.stderr)
+	}
+	if pipes.Stdin != nil {
+		go func() {
+			io.Copy(client.stdin, pipes.Stdin)
+			client.send(&shimRequest{Action: "close-stdin"})
+		}()
+	} else {
+		client.send(&shimRequest{Action: "close-stdin"})
+	}
+
+	c.ProcessConfig.Terminal = client
+	// os.FindProcess always succeeds on unix
+	c.ProcessConfig.Process, _ = os.FindProcess(msg.Pid)
+	c.ContainerPid = msg.Pid
+	if startCallback != nil {
+		startCallback(&c.ProcessConfig, c.ContainerPid)
+	}
+
+	for msg.Status == nil {
+		if msg, err = client.receive(); err != nil {
+			// the shim went away without telling, look for the status it left
+			status, serr := readShimExitStatus(filepath.Join(d.root, c.ID))
+			if serr != nil {
+				return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("lost the shim of container %s: %s", c.ID, err)
+			}
+			msg = &shimMessage{Status: status}
+		}
+	}
+	wg.Wait()
+
+	if msg.Error != "" {
+		return *msg.Status, fmt.Errorf("%s", msg.Error)
+	}
+	return *msg.Status, nil
+}
+
+// newShimPipes returns a client connected to the files to pass to a new shim.
+func newShimPipes() (*shimClient, []*os.File, error) {
+	var (
+		conns = make([]net.Conn, 4)
+		files = make([]*os.File, 4)
+	)
+	for i := range conns {
+		fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		local := os.NewFile(uintptr(fds[0]), "shim")
+		conns[i], err = net.FileConn(local)
+		local.Close()
+		files[i] = os.NewFile(uintptr(fds[1]), "shim")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return newShimClient(conns[0], conns[1], conns[2], conns[3]), files, nil
+}
+
+// dialShim connects to the shim of a running container.
+func dialShim(id string, tty bool) (*shimClient, error) {
+	streams := []string{"control", "stdin", "stdout"}
+	if !tty {
+		streams = append(streams, "stderr")
+	}
+
+	conns := make([]net.Conn, 4)
+	for i, stream := range streams {
+		conn, err := net.Dial("unix", shimAddress(id))
+		if err == nil {
+			_, err = conn.Write([]byte(stream + "\n"))
+		}
+		if err != nil {
+			for _, conn := range conns[:i] {
+				conn.Close()
+			}
+			return nil, err
+		}
+		conns[i] = conn
+	}
+	return newShimClient(conns[0], conns[1], conns[2], conns[3]), nil
+}
+
+func newShimClient(control, stdin, stdout, stderr net.Conn) *shimClient {
+	return &shimClient{
+		control: control,
+		dec:     json.NewDecoder(control),
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+	}
+}
+
+func (s *shimClient) receive() (*shimMessage, error) {
+	var msg shimMessage
+	if err := s.dec.Decode(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (s *shimClient) send(req *shimRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(s.control).Encode(req)
+}
+
+func (s *shimClient) Resize(h, w int) error {
+	return s.send(&shimRequest{Action: "resize", Height: h, Width: w})
+}
+
+func (s *shimClient) Close() error {
+	s.closeOnce.Do(func() {
+		for _, conn := range []net.Conn{s.control, s.stdin, s.stdout, s.stderr} {
+			if conn != nil {
+				conn.Close()
+			}
+		}
+	})
+	return nil
+}
+
+func readShimExitStatus(dataPath string) (*execdriver.ExitStatus, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dataPath, shimExitFile))
+	if err != nil {
+		return nil, err
+	}
+	var status execdriver.ExitStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
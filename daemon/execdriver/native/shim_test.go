@@ -0,0 +1,83 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShimOutputKeepsRecentOutputWhileDetached(t *testing.T) {
+	o := newShimOutput()
+
+	// more output than the shim keeps while no daemon reads it
+	data := bytes.Repeat([]byte("a"), shimOutputBufferSize-4)
+	r, w := io.Pipe()
+	go o.copyFrom(r)
+	if _, err := w.Write([]byte("lost" + string(data) + "kept")); err != nil {
+		t.Fatal(err)
+	}
+	// wait for the shim to have read all of it
+	for {
+		o.mu.Lock()
+		done := bytes.HasSuffix(o.buf, []byte("kept"))
+		o.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	daemon, shim := net.Pipe()
+	o.attach(shim)
+	w.Close()
+	out, err := ioutil.ReadAll(daemon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.wait()
+
+	if len(out) != shimOutputBufferSize {
+		t.Fatalf("Expected %d bytes of output, got %d", shimOutputBufferSize, len(out))
+	}
+	if bytes.HasPrefix(out, []byte("lost")) || !bytes.HasSuffix(out, []byte("kept")) {
+		t.Fatal("Expected the oldest output to be dropped")
+	}
+}
+
+func TestShimOutputDropsOutputWithoutDaemon(t *testing.T) {
+	o := newShimOutput()
+	o.copyFrom(strings.NewReader("output"))
+	// the stream ended with nobody attached
+	o.wait()
+
+	daemon, shim := net.Pipe()
+	o.attach(shim)
+	out, err := ioutil.ReadAll(daemon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 0 {
+		t.Fatalf("Expected no output, got %q", out)
+	}
+}
+
+func TestReadShimLine(t *testing.T) {
+	r := strings.NewReader("stdin\ndata")
+	line, err := readShimLine(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "stdin" {
+		t.Fatalf("Expected stdin, got %s", line)
+	}
+	rest, _ := ioutil.ReadAll(r)
+	if string(rest) != "data" {
+		t.Fatalf("Expected the data after the line to be left, got %q", rest)
+	}
+}
@@ -0,0 +1,29 @@
+// +build linux,cgo,go1.5
+
+package native
+
+import (
+	"syscall"
+
+	"github.com/docker/libcontainer"
+)
+
+const userNamespaceSupported = true
+
+// setupUserNamespace makes the init of container run as root in its new
+// user namespace
+func setupUserNamespace(sys *syscall.SysProcAttr, container *libcontainer.Config) {
+	sys.UidMappings = sysProcIDMap(container.UidMappings)
+	sys.GidMappings = sysProcIDMap(container.GidMappings)
+	// the init sets the groups of the user of the container
+	sys.GidMappingsEnableSetgroups = true
+	sys.Credential = &syscall.Credential{Uid: 0, Gid: 0}
+}
+
+func sysProcIDMap(idMap []libcontainer.IDMap) []syscall.SysProcIDMap {
+	var m []syscall.SysProcIDMap
+	for _, id := range idMap {
+		m = append(m, syscall.SysProcIDMap{ContainerID: id.ContainerID, HostID: id.HostID, Size: id.Size})
+	}
+	return m
+}
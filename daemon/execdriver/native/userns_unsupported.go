@@ -0,0 +1,15 @@
+// +build linux,cgo,!go1.4
+
+package native
+
+import (
+	"syscall"
+
+	"github.com/docker/libcontainer"
+)
+
+// the id mappings of a new user namespace are set up by Go 1.4 and later
+const userNamespaceSupported = false
+
+func setupUserNamespace(sys *syscall.SysProcAttr, container *libcontainer.Config) {
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
 )
 
 type FsMagic uint64
@@ -16,7 +17,9 @@ const (
 	FsMagicAufs  = FsMagic(0x61756673)
 )
 
-type InitFunc func(root string, options []string) (Driver, error)
+// InitFunc initializes a driver at root.  The owners of the files of its
+// layers are mapped from container to host ids with uidMaps and gidMaps.
+type InitFunc func(root string, options []string, uidMaps, gidMaps []idtools.IDMap) (Driver, error)
 
 // ProtoDriver defines the basic capabilities of a driver.
 // This interface exists solely to be a minimum set of methods
@@ -84,9 +87,10 @@ var (
 		"overlay",
 	}
 
-	ErrNotSupported   = errors.New("driver not supported")
-	ErrPrerequisites  = errors.New("prerequisites for driver not satisfied (wrong filesystem?)")
-	ErrIncompatibleFS = fmt.Errorf("backing file system is unsupported for this graph driver")
+	ErrNotSupported       = errors.New("driver not supported")
+	ErrPrerequisites      = errors.New("prerequisites for driver not satisfied (wrong filesystem?)")
+	ErrIncompatibleFS     = fmt.Errorf("backing file system is unsupported for this graph driver")
+	ErrIDMapsNotSupported = errors.New("driver does not support remapping the user namespace")
 )
 
 func init() {
@@ -102,25 +106,25 @@ func Register(name string, initFunc InitFunc) error {
 	return nil
 }
 
-func GetDriver(name, home string, options []string) (Driver, error) {
+func GetDriver(name, home string, options []string, uidMaps, gidMaps []idtools.IDMap) (Driver, error) {
 	if initFunc, exists := drivers[name]; exists {
-		return initFunc(path.Join(home, name), options)
+		return initFunc(path.Join(home, name), options, uidMaps, gidMaps)
 	}
 	return nil, ErrNotSupported
 }
 
-func New(root string, options []string) (driver Driver, err error) {
+func New(root string, options []string, uidMaps, gidMaps []idtools.IDMap) (driver Driver, err error) {
 	for _, name := range []string{os.Getenv("DOCKER_DRIVER"), DefaultDriver} {
 		if name != "" {
-			return GetDriver(name, root, options)
+			return GetDriver(name, root, options, uidMaps, gidMaps)
 		}
 	}
 
 	// Check for priority drivers first
 	for _, name := range priority {
-		driver, err = GetDriver(name, root, options)
+		driver, err = GetDriver(name, root, options, uidMaps, gidMaps)
 		if err != nil {
-			if err == ErrNotSupported || err == ErrPrerequisites || err == ErrIncompatibleFS {
+			if err == ErrNotSupported || err == ErrPrerequisites || err == ErrIncompatibleFS || err == ErrIDMapsNotSupported {
 				continue
 			}
 			return nil, err
@@ -130,8 +134,8 @@ func New(root string, options []string) (driver Driver, err error) {
 
 	// Check all registered drivers if no priority driver is found
 	for _, initFunc := range drivers {
-		if driver, err = initFunc(root, options); err != nil {
-			if err == ErrNotSupported || err == ErrPrerequisites || err == ErrIncompatibleFS {
+		if driver, err = initFunc(root, options, uidMaps, gidMaps); err != nil {
+			if err == ErrNotSupported || err == ErrPrerequisites || err == ErrIncompatibleFS || err == ErrIDMapsNotSupported {
 				continue
 			}
 			return nil, err
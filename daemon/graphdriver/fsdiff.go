@@ -9,6 +9,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/utils"
 )
@@ -20,6 +21,8 @@ import (
 // Notably, the AUFS driver doesn't need to be wrapped like this.
 type naiveDiffDriver struct {
 	ProtoDriver
+	uidMaps []idtools.IDMap
+	gidMaps []idtools.IDMap
 }
 
 // NaiveDiffDriver returns a fully functional driver that wraps the
@@ -29,8 +32,10 @@ type naiveDiffDriver struct {
 //     Changes(id, parent string) ([]archive.Change, error)
 //     ApplyDiff(id, parent string, diff archive.ArchiveReader) (bytes int64, err error)
 //     DiffSize(id, parent string) (bytes int64, err error)
-func NaiveDiffDriver(driver ProtoDriver) Driver {
-	return &naiveDiffDriver{ProtoDriver: driver}
+// The owners of the files in the diffs are container ids, mapped to host ids
+// with uidMaps and gidMaps.
+func NaiveDiffDriver(driver ProtoDriver, uidMaps, gidMaps []idtools.IDMap) Driver {
+	return &naiveDiffDriver{ProtoDriver: driver, uidMaps: uidMaps, gidMaps: gidMaps}
 }
 
 // Diff produces an archive of the changes between the specified
@@ -50,7 +55,11 @@ func (gdw *naiveDiffDriver) Diff(id, parent string) (arch archive.Archive, err e
 	}()
 
 	if parent == "" {
-		archive, err := archive.Tar(layerFs, archive.Uncompressed)
+		archive, err := archive.TarWithOptions(layerFs, &archive.TarOptions{
+			Compression: archive.Uncompressed,
+			UIDMaps:     gdw.uidMaps,
+			GIDMaps:     gdw.gidMaps,
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -72,7 +81,7 @@ func (gdw *naiveDiffDriver) Diff(id, parent string) (arch archive.Archive, err e
 		return nil, err
 	}
 
-	archive, err := archive.ExportChanges(layerFs, changes)
+	archive, err := archive.ExportChanges(layerFs, changes, gdw.uidMaps, gdw.gidMaps)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +132,8 @@ func (gdw *naiveDiffDriver) ApplyDiff(id, parent string, diff archive.ArchiveRea
 
 	start := time.Now().UTC()
 	log.Debugf("Start untar layer")
-	if err = chrootarchive.ApplyLayer(layerFs, diff); err != nil {
+	options := &archive.TarOptions{UIDMaps: gdw.uidMaps, GIDMaps: gdw.gidMaps}
+	if err = chrootarchive.ApplyLayerWithOptions(layerFs, diff, options); err != nil {
 		return
 	}
 	log.Debugf("Untar time: %vs", time.Now().UTC().Sub(start).Seconds())
@@ -2,6 +2,7 @@ package graphtest
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -9,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
 )
 
 var (
@@ -71,7 +74,7 @@ func newDriver(t *testing.T, name string) *Driver {
 		t.Fatal(err)
 	}
 
-	d, err := graphdriver.GetDriver(name, root, nil)
+	d, err := graphdriver.GetDriver(name, root, nil, nil, nil)
 	if err != nil {
 		if err == graphdriver.ErrNotSupported || err == graphdriver.ErrPrerequisites {
 			t.Skipf("Driver %s not supported", name)
@@ -268,3 +271,83 @@ func DriverTestCreateSnap(t *testing.T, drivername string) {
 		t.Fatal(err)
 	}
 }
+
+// Creates a driver remapping the container ids and verifies the owners of
+// its layers and diffs
+func DriverTestRemappedDiff(t *testing.T, drivername string) {
+	root, err := ioutil.TempDir("/var/tmp", "docker-graphtest-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	idMap := []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	driver, err := graphdriver.GetDriver(drivername, root, nil, idMap, idMap)
+	if err != nil {
+		if err == graphdriver.ErrNotSupported || err == graphdriver.ErrPrerequisites {
+			t.Skipf("Driver %s not supported", drivername)
+		}
+		t.Fatal(err)
+	}
+	defer driver.Cleanup()
+
+	if err := driver.Create("Base", ""); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := driver.Get("Base", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyFile(t, dir, 0755|os.ModeDir, 100000, 100000)
+	if err := ioutil.WriteFile(path.Join(dir, "a file"), []byte("Some data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(path.Join(dir, "a file"), 100001, 100002); err != nil {
+		t.Fatal(err)
+	}
+	driver.Put("Base")
+
+	diff, err := driver.Diff("Base", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(diff)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == "a file" {
+			found = true
+			if hdr.Uid != 1 || hdr.Gid != 2 {
+				t.Fatalf("Expected the diff to be owned by 1:2, got %d:%d", hdr.Uid, hdr.Gid)
+			}
+		}
+	}
+	diff.Close()
+	if !found {
+		t.Fatal("Diff of the base layer misses its file")
+	}
+
+	diff, err = driver.Diff("Base", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer diff.Close()
+	if err := driver.Create("Applied", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := driver.ApplyDiff("Applied", "", diff); err != nil {
+		t.Fatal(err)
+	}
+	dir, err = driver.Get("Applied", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Put("Applied")
+	verifyFile(t, path.Join(dir, "a file"), 0644, 100001, 100002)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/libcontainer/label"
 )
 
@@ -36,9 +37,9 @@ type naiveDiffDriverWithApply struct {
 	applyDiff ApplyDiffProtoDriver
 }
 
-func NaiveDiffDriverWithApply(driver ApplyDiffProtoDriver) graphdriver.Driver {
+func NaiveDiffDriverWithApply(driver ApplyDiffProtoDriver, uidMaps, gidMaps []idtools.IDMap) graphdriver.Driver {
 	return &naiveDiffDriverWithApply{
-		Driver:    graphdriver.NaiveDiffDriver(driver),
+		Driver:    graphdriver.NaiveDiffDriver(driver, uidMaps, gidMaps),
 		applyDiff: driver,
 	}
 }
@@ -88,28 +89,36 @@ type Driver struct {
 	home       string
 	sync.Mutex // Protects concurrent modification to active
 	active     map[string]*ActiveMount
+	uidMaps    []idtools.IDMap
+	gidMaps    []idtools.IDMap
 }
 
 func init() {
 	graphdriver.Register("overlay", Init)
 }
 
-func Init(home string, options []string) (graphdriver.Driver, error) {
+func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (graphdriver.Driver, error) {
 	if err := supportsOverlay(); err != nil {
 		return nil, graphdriver.ErrNotSupported
 	}
 
+	rootUID, rootGID, err := idtools.GetRootUIDGID(uidMaps, gidMaps)
+	if err != nil {
+		return nil, err
+	}
 	// Create the driver home dir
-	if err := os.MkdirAll(home, 0755); err != nil && !os.IsExist(err) {
+	if err := idtools.MkdirAllAs(home, 0755, rootUID, rootGID); err != nil {
 		return nil, err
 	}
 
 	d := &Driver{
-		home:   home,
-		active: make(map[string]*ActiveMount),
+		home:    home,
+		active:  make(map[string]*ActiveMount),
+		uidMaps: uidMaps,
+		gidMaps: gidMaps,
 	}
 
-	return NaiveDiffDriverWithApply(d), nil
+	return NaiveDiffDriverWithApply(d, uidMaps, gidMaps), nil
 }
 
 func supportsOverlay() error {
@@ -147,12 +156,21 @@ func (d *Driver) Cleanup() error {
 
 func (d *Driver) Create(id string, parent string) (retErr error) {
 	dir := d.dir(id)
-	if err := os.MkdirAll(path.Dir(dir), 0700); err != nil {
+	// the directories are owned by root in the container so that it can
+	// reach its root filesystem
+	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
+	if err != nil {
+		return err
+	}
+	if err := idtools.MkdirAllAs(path.Dir(dir), 0700, rootUID, rootGID); err != nil {
 		return err
 	}
 	if err := os.Mkdir(dir, 0700); err != nil {
 		return err
 	}
+	if err := os.Chown(dir, rootUID, rootGID); err != nil {
+		return err
+	}
 
 	defer func() {
 		// Clean up on failure
@@ -166,7 +184,7 @@ func (d *Driver) Create(id string, parent string) (retErr error) {
 		if err := os.Mkdir(path.Join(dir, "root"), 0755); err != nil {
 			return err
 		}
-		return nil
+		return os.Chown(path.Join(dir, "root"), rootUID, rootGID)
 	}
 
 	parentDir := d.dir(parent)
@@ -183,6 +201,9 @@ func (d *Driver) Create(id string, parent string) (retErr error) {
 		if err := os.Mkdir(path.Join(dir, "upper"), s.Mode()); err != nil {
 			return err
 		}
+		if err := os.Chown(path.Join(dir, "upper"), rootUID, rootGID); err != nil {
+			return err
+		}
 		if err := os.Mkdir(path.Join(dir, "work"), 0700); err != nil {
 			return err
 		}
@@ -216,6 +237,9 @@ func (d *Driver) Create(id string, parent string) (retErr error) {
 	if err := os.Mkdir(upperDir, s.Mode()); err != nil {
 		return err
 	}
+	if err := os.Chown(upperDir, rootUID, rootGID); err != nil {
+		return err
+	}
 	if err := os.Mkdir(path.Join(dir, "work"), 0700); err != nil {
 		return err
 	}
@@ -347,7 +371,8 @@ func (d *Driver) ApplyDiff(id string, parent string, diff archive.ArchiveReader)
 		return 0, err
 	}
 
-	if err := chrootarchive.ApplyLayer(tmpRootDir, diff); err != nil {
+	options := &archive.TarOptions{UIDMaps: d.uidMaps, GIDMaps: d.gidMaps}
+	if err := chrootarchive.ApplyLayerWithOptions(tmpRootDir, diff, options); err != nil {
 		return 0, err
 	}
 
@@ -1,10 +1,16 @@
 package overlay
 
 import (
-	"github.com/docker/docker/daemon/graphdriver/graphtest"
 	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver/graphtest"
+	"github.com/docker/docker/pkg/reexec"
 )
 
+func init() {
+	reexec.Init()
+}
+
 // This avoids creating a new driver for each test if all tests are run
 // Make sure to put new tests between TestOverlaySetup and TestOverlayTeardown
 func TestOverlaySetup(t *testing.T) {
@@ -23,6 +29,10 @@ func TestOverlayCreateSnap(t *testing.T) {
 	graphtest.DriverTestCreateSnap(t, "overlay")
 }
 
+func TestOverlayRemappedDiff(t *testing.T) {
+	graphtest.DriverTestRemappedDiff(t, "overlay")
+}
+
 func TestOverlayTeardown(t *testing.T) {
 	graphtest.PutDriver(t)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/libcontainer/label"
 )
 
@@ -16,15 +17,19 @@ func init() {
 	graphdriver.Register("vfs", Init)
 }
 
-func Init(home string, options []string) (graphdriver.Driver, error) {
+func Init(home string, options []string, uidMaps, gidMaps []idtools.IDMap) (graphdriver.Driver, error) {
 	d := &Driver{
-		home: home,
+		home:    home,
+		uidMaps: uidMaps,
+		gidMaps: gidMaps,
 	}
-	return graphdriver.NaiveDiffDriver(d), nil
+	return graphdriver.NaiveDiffDriver(d, uidMaps, gidMaps), nil
 }
 
 type Driver struct {
-	home string
+	home    string
+	uidMaps []idtools.IDMap
+	gidMaps []idtools.IDMap
 }
 
 func (d *Driver) String() string {
@@ -49,12 +54,21 @@ func isGNUcoreutils() bool {
 
 func (d *Driver) Create(id, parent string) error {
 	dir := d.dir(id)
-	if err := os.MkdirAll(path.Dir(dir), 0700); err != nil {
+	// the directories are owned by root in the container so that it can
+	// reach its root filesystem
+	rootUID, rootGID, err := idtools.GetRootUIDGID(d.uidMaps, d.gidMaps)
+	if err != nil {
+		return err
+	}
+	if err := idtools.MkdirAllAs(path.Dir(dir), 0700, rootUID, rootGID); err != nil {
 		return err
 	}
 	if err := os.Mkdir(dir, 0755); err != nil {
 		return err
 	}
+	if err := os.Chown(dir, rootUID, rootGID); err != nil {
+		return err
+	}
 	opts := []string{"level:s0"}
 	if _, mountLabel, err := label.InitLabels(opts); err == nil {
 		label.Relabel(dir, mountLabel, "")
@@ -0,0 +1,323 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/runconfig"
+)
+
+const (
+	defaultHealthInterval = 30 * time.Second
+	defaultHealthTimeout  = 30 * time.Second
+	defaultHealthRetries  = 3
+
+	// maxHealthLogEntries is the number of health check results kept
+	// in the container state
+	maxHealthLogEntries = 5
+	// maxHealthOutputSize is the maximum number of bytes of a check's
+	// output kept in its result
+	maxHealthOutputSize = 4096
+)
+
+// Health status of a container
+const (
+	HealthStarting  = "starting"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// Health holds the outcome of the health checks of a container.
+type Health struct {
+	Status        string
+	FailingStreak int
+	Log           []*HealthResult
+}
+
+// HealthResult is the outcome of a single health check.
+type HealthResult struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int
+	Output   string
+}
+
+// healthMonitor periodically runs the health check of a running container.
+type healthMonitor struct {
+	container *Container
+	test      []string
+	interval  time.Duration
+	timeout   time.Duration
+	retries   int
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newHealthMonitor(container *Container, config *runconfig.HealthConfig) *healthMonitor {
+	h := &healthMonitor{
+		container: container,
+		test:      config.Test,
+		interval:  config.Interval,
+		timeout:   config.Timeout,
+		retries:   config.Retries,
+		stop:      make(chan struct{}),
+	}
+	if h.interval == 0 {
+		h.interval = defaultHealthInterval
+	}
+	if h.timeout == 0 {
+		h.timeout = defaultHealthTimeout
+	}
+	if h.retries == 0 {
+		h.retries = defaultHealthRetries
+	}
+	return h
+}
+
+// hasHealthcheck returns true if the container has a health check configured.
+func (container *Container) hasHealthcheck() bool {
+	config := container.Config.Healthcheck
+	return config != nil && len(config.Test) > 0 && config.Test[0] != "NONE"
+}
+
+// startHealthcheck starts probing the container, if it has a health check
+// configured. Like setRunning, it must be called with the container lock
+// held once the container process is running.
+func (container *Container) startHealthcheck() {
+	if container.healthMonitor != nil {
+		container.healthMonitor.Stop()
+		container.healthMonitor = nil
+	}
+	if !container.hasHealthcheck() {
+		container.Health = nil
+		return
+	}
+	container.healthMonitor = newHealthMonitor(container, container.Config.Healthcheck)
+	container.Health = &Health{Status: HealthStarting}
+	go container.healthMonitor.run()
+}
+
+// stopHealthcheck stops probing the container.
+func (container *Container) stopHealthcheck() {
+	container.Lock()
+	h := container.healthMonitor
+	container.healthMonitor = nil
+	container.Unlock()
+	if h != nil {
+		h.Stop()
+	}
+}
+
+func (h *healthMonitor) Stop() {
+	h.stopOnce.Do(func() { close(h.stop) })
+}
+
+func (h *healthMonitor) run() {
+	for {
+		select {
+		case <-time.After(h.interval):
+		case <-h.stop:
+			return
+		}
+		if h.container.IsPaused() {
+			continue
+		}
+		result := h.probe()
+		select {
+		case <-h.stop:
+			// the container stopped while we were probing it
+			return
+		default:
+		}
+		h.record(result)
+	}
+}
+
+// record stores the result of a check in the container state and acts on
+// a change of health status.
+func (h *healthMonitor) record(result *HealthResult) {
+	container := h.container
+
+	container.Lock()
+	health := container.Health
+	if health == nil {
+		health = &Health{Status: HealthStarting}
+		container.Health = health
+	}
+	oldStatus := health.Status
+	health.Log = append(health.Log, result)
+	if len(health.Log) > maxHealthLogEntries {
+		health.Log = health.Log[len(health.Log)-maxHealthLogEntries:]
+	}
+	if result.ExitCode == 0 {
+		health.FailingStreak = 0
+		health.Status = HealthHealthy
+	} else {
+		health.FailingStreak++
+		if health.FailingStreak >= h.retries {
+			health.Status = HealthUnhealthy
+		}
+	}
+	newStatus := health.Status
+	container.Unlock()
+
+	if err := container.ToDisk(); err != nil {
+		log.Debugf("%s", err)
+	}
+
+	if newStatus == oldStatus {
+		return
+	}
+	container.LogEventAttributes("health_status", map[string]string{"healthStatus": newStatus})
+
+	if newStatus == HealthUnhealthy && container.hostConfig != nil && container.hostConfig.RestartPolicy.OnUnhealthy {
+		log.Infof("Container %s is unhealthy, killing it", container.ID)
+		// Kill the process without telling the monitor so that the
+		// restart policy applies as for any other failure.
+		if err := container.daemon.Kill(container, int(syscall.SIGKILL)); err != nil {
+			log.Errorf("Error killing unhealthy container %s: %s", container.ID, err)
+		}
+	}
+}
+
+// probe runs the health check once.
+func (h *healthMonitor) probe() *HealthResult {
+	result := &HealthResult{Start: time.Now().UTC()}
+
+	var (
+		output string
+		err    error
+	)
+	switch h.test[0] {
+	case "CMD":
+		result.ExitCode, output, err = h.probeExec(h.test[1:])
+	case "CMD-SHELL":
+		shell := h.container.Config.Shell
+		if len(shell) == 0 {
+			shell = []string{"/bin/sh", "-c"}
+		}
+		result.ExitCode, output, err = h.probeExec(append(append([]string{}, shell...), h.test[1:]...))
+	case "TCP":
+		err = h.probeTCP()
+	case "HTTP":
+		output, err = h.probeHTTP()
+	default:
+		err = fmt.Errorf("unknown health check type %s", h.test[0])
+	}
+	if err != nil {
+		result.ExitCode = -1
+		output = err.Error()
+	}
+	if len(output) > maxHealthOutputSize {
+		output = output[:maxHealthOutputSize]
+	}
+	result.Output = output
+	result.End = time.Now().UTC()
+	return result
+}
+
+// probeExec runs args inside the container through the exec driver and
+// returns the exit code and combined output of the command.
+func (h *healthMonitor) probeExec(args []string) (int, string, error) {
+	if len(args) == 0 {
+		return -1, "", fmt.Errorf("empty health check command")
+	}
+	container := h.container
+
+	container.Lock()
+	command := container.command
+	container.Unlock()
+	if command == nil {
+		return -1, "", fmt.Errorf("container %s is not running", container.ID)
+	}
+
+	var (
+		output        = &bytes.Buffer{}
+		pipes         = execdriver.NewPipes(nil, output, output, false)
+		processConfig = &execdriver.ProcessConfig{
+			Entrypoint: args[0],
+			Arguments:  args[1:],
+		}
+		pid  = make(chan int, 1)
+		done = make(chan error, 1)
+		code int
+	)
+	go func() {
+		var err error
+		code, err = container.daemon.execDriver.Exec(command, processConfig, pipes, func(_ *execdriver.ProcessConfig, p int) {
+			pid <- p
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return -1, "", err
+		}
+		return code, output.String(), nil
+	case <-time.After(h.timeout):
+		select {
+		case p := <-pid:
+			syscall.Kill(p, syscall.SIGKILL)
+		default:
+		}
+		return -1, "", fmt.Errorf("health check exceeded timeout (%s)", h.timeout)
+	}
+}
+
+func (h *healthMonitor) address(port string) (string, error) {
+	h.container.Lock()
+	ip := h.container.NetworkSettings.IPAddress
+	h.container.Unlock()
+	if ip == "" {
+		ip = "127.0.0.1"
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", fmt.Errorf("invalid health check port %s", port)
+	}
+	return net.JoinHostPort(ip, port), nil
+}
+
+func (h *healthMonitor) probeTCP() error {
+	if len(h.test) != 2 {
+		return fmt.Errorf("invalid TCP health check %v", h.test)
+	}
+	addr, err := h.address(h.test[1])
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", addr, h.timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (h *healthMonitor) probeHTTP() (string, error) {
+	if len(h.test) != 3 {
+		return "", fmt.Errorf("invalid HTTP health check %v", h.test)
+	}
+	addr, err := h.address(h.test[1])
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Timeout: h.timeout}
+	resp, err := client.Get("http://" + addr + h.test[2])
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.Status, nil
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/events"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/parsers"
@@ -100,7 +101,7 @@ func (daemon *Daemon) DeleteImage(eng *engine.Engine, name string, imgs *engine.
 			out := &engine.Env{}
 			out.Set("Untagged", repoName+":"+tag)
 			imgs.Add(out)
-			eng.Job("log", "untag", img.ID, "").Run()
+			events.LogEvent(eng, events.ImageEventType, "untag", img.ID, "", graph.ImageEventAttributes(img, map[string]string{"name": repoName + ":" + tag}))
 		}
 	}
 	tags = daemon.Repositories().ByID()[img.ID]
@@ -115,7 +116,7 @@ func (daemon *Daemon) DeleteImage(eng *engine.Engine, name string, imgs *engine.
 			out := &engine.Env{}
 			out.Set("Deleted", img.ID)
 			imgs.Add(out)
-			eng.Job("log", "delete", img.ID, "").Run()
+			events.LogEvent(eng, events.ImageEventType, "delete", img.ID, "", graph.ImageEventAttributes(img, nil))
 			if img.Parent != "" && !noprune {
 				err := daemon.DeleteImage(eng, img.Parent, imgs, false, force, noprune)
 				if first {
@@ -2,7 +2,6 @@ package daemon
 
 import (
 	"strconv"
-	"strings"
 	"syscall"
 
 	"github.com/docker/docker/engine"
@@ -20,37 +19,33 @@ func (daemon *Daemon) ContainerKill(job *engine.Job) engine.Status {
 	var (
 		name = job.Args[0]
 		sig  uint64
-		err  error
 	)
 
 	// If we have a signal, look at it. Otherwise, do nothing
 	if len(job.Args) == 2 && job.Args[1] != "" {
-		// Check if we passed the signal as a number:
-		// The largest legal signal is 31, so let's parse on 5 bits
-		sig, err = strconv.ParseUint(job.Args[1], 10, 5)
+		s, err := signal.ParseSignal(job.Args[1])
 		if err != nil {
-			// The signal is not a number, treat it as a string (either like "KILL" or like "SIGKILL")
-			sig = uint64(signal.SignalMap[strings.TrimPrefix(job.Args[1], "SIG")])
-		}
-
-		if sig == 0 {
-			return job.Errorf("Invalid signal: %s", job.Args[1])
+			return job.Error(err)
 		}
+		sig = uint64(s)
 	}
 
 	if container := daemon.Get(name); container != nil {
 		// If no signal is passed, or SIGKILL, perform regular Kill (SIGKILL + wait())
 		if sig == 0 || syscall.Signal(sig) == syscall.SIGKILL {
+			// Other signals don't necessarily stop the container, so only
+			// SIGKILL counts as stopped by the user, as with docker stop
+			container.setManuallyStopped()
 			if err := container.Kill(); err != nil {
 				return job.Errorf("Cannot kill container %s: %s", name, err)
 			}
-			container.LogEvent("kill")
+			container.LogEventAttributes("kill", map[string]string{"signal": strconv.Itoa(int(syscall.SIGKILL))})
 		} else {
 			// Otherwise, just send the requested signal
 			if err := container.KillSig(int(sig)); err != nil {
 				return job.Errorf("Cannot kill container %s: %s", name, err)
 			}
-			// FIXME: Add event for signals
+			container.LogEventAttributes("kill", map[string]string{"signal": strconv.FormatUint(sig, 10)})
 		}
 	} else {
 		return job.Errorf("No such container: %s", name)
@@ -112,6 +112,10 @@ func (daemon *Daemon) Containers(job *engine.Job) engine.Status {
 		if !psFilters.Match("status", container.State.StateString()) {
 			return nil
 		}
+
+		if !psFilters.MatchKVList("label", container.Config.Labels) {
+			return nil
+		}
 		displayed++
 		out := &engine.Env{}
 		out.Set("Id", container.ID)
@@ -139,6 +143,7 @@ func (daemon *Daemon) Containers(job *engine.Job) engine.Status {
 			return err
 		}
 		out.Set("Ports", str)
+		out.SetJson("Labels", container.Config.Labels)
 		if size {
 			sizeRw, sizeRootFs := container.GetSize()
 			out.SetInt64("SizeRw", sizeRw)
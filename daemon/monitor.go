@@ -2,7 +2,9 @@ package daemon
 
 import (
 	"io"
+	"math/rand"
 	"os/exec"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,7 +13,15 @@ import (
 	"github.com/docker/docker/runconfig"
 )
 
-const defaultTimeIncrement = 100
+const (
+	// defaultTimeIncrement is the delay before the first restart of a container
+	defaultTimeIncrement = 100 * time.Millisecond
+	// defaultMaxTimeIncrement caps the delay between two restarts
+	defaultMaxTimeIncrement = time.Minute
+	// defaultResetWindow is the execution time after which a container is
+	// considered to have started successfully and the delay is reset
+	defaultResetWindow = 10 * time.Second
+)
 
 // containerMonitor monitors the execution of a container's main process.
 // If a restart policy is specified for the cotnainer the monitor will ensure that the
@@ -44,11 +54,14 @@ type containerMonitor struct {
 	stopChan chan struct{}
 
 	// timeIncrement is the amount of time to wait between restarts
-	// this is in milliseconds
-	timeIncrement int
+	timeIncrement time.Duration
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// reattaching is true until the monitor re-attached to the process left
+	// running by a previous daemon
+	reattaching bool
 }
 
 // newContainerMonitor returns an initialized containerMonitor for the provided container
@@ -57,7 +70,7 @@ func newContainerMonitor(container *Container, policy runconfig.RestartPolicy) *
 	return &containerMonitor{
 		container:     container,
 		restartPolicy: policy,
-		timeIncrement: defaultTimeIncrement,
+		timeIncrement: initialTimeIncrement(policy),
 		stopChan:      make(chan struct{}),
 		startSignal:   make(chan struct{}),
 	}
@@ -116,8 +129,13 @@ func (m *containerMonitor) Start() error {
 		m.Close()
 	}()
 
-	// reset the restart count
-	m.container.RestartCount = -1
+	if m.reattaching {
+		// keep counting the restarts of the previous daemon
+		m.container.RestartCount--
+	} else {
+		// reset the restart count
+		m.container.RestartCount = -1
+	}
 
 	for {
 		m.container.RestartCount++
@@ -130,14 +148,21 @@ func (m *containerMonitor) Start() error {
 
 		pipes := execdriver.NewPipes(m.container.stdin, m.container.stdout, m.container.stderr, m.container.Config.OpenStdin)
 
-		m.container.LogEvent("start")
+		if m.reattaching {
+			m.lastStartTime = m.container.StartedAt
 
-		m.lastStartTime = time.Now()
+			exitStatus, err = m.container.daemon.Reattach(m.container, pipes, m.reattachCallback)
+		} else {
+			m.container.LogEvent("start")
 
-		if exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback); err != nil {
+			m.lastStartTime = time.Now()
+
+			exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback)
+		}
+		if err != nil {
 			// if we receive an internal error from the initial start of a container then lets
 			// return it instead of entering the restart loop
-			if m.container.RestartCount == 0 {
+			if m.container.RestartCount == 0 && !m.reattaching {
 				m.container.ExitCode = -1
 				m.resetContainer(false)
 
@@ -149,12 +174,15 @@ func (m *containerMonitor) Start() error {
 
 		// here container.Lock is already lost
 		afterRun = true
+		m.reattaching = false
+
+		m.container.stopHealthcheck()
 
 		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
 
 		if m.shouldRestart(exitStatus.ExitCode) {
 			m.container.SetRestarting(&exitStatus)
-			m.container.LogEvent("die")
+			m.container.LogEventAttributes("die", map[string]string{"exitCode": strconv.Itoa(exitStatus.ExitCode)})
 			m.resetContainer(true)
 
 			// sleep with a small time increment between each restart to help avoid issues cased by quickly
@@ -170,24 +198,46 @@ func (m *containerMonitor) Start() error {
 			continue
 		}
 		m.container.ExitCode = exitStatus.ExitCode
-		m.container.LogEvent("die")
+		m.container.LogEventAttributes("die", map[string]string{"exitCode": strconv.Itoa(exitStatus.ExitCode)})
 		m.resetContainer(true)
 		return err
 	}
 }
 
+// initialTimeIncrement returns the delay before the first restart of a
+// container with the given policy
+func initialTimeIncrement(policy runconfig.RestartPolicy) time.Duration {
+	if policy.InitialDelay > 0 {
+		return policy.InitialDelay
+	}
+	return defaultTimeIncrement
+}
+
 // resetMonitor resets the stateful fields on the containerMonitor based on the
 // previous runs success or failure.  Reguardless of success, if the container had
-// an execution time of more than 10s then reset the timer back to the default
+// an execution time of more than the policy's reset window then reset the timer
+// back to the initial delay
 func (m *containerMonitor) resetMonitor(successful bool) {
-	executionTime := time.Now().Sub(m.lastStartTime).Seconds()
+	executionTime := time.Now().Sub(m.lastStartTime)
+
+	resetWindow := m.restartPolicy.ResetWindow
+	if resetWindow <= 0 {
+		resetWindow = defaultResetWindow
+	}
+	maxTimeIncrement := m.restartPolicy.MaximumDelay
+	if maxTimeIncrement <= 0 {
+		maxTimeIncrement = defaultMaxTimeIncrement
+	}
 
-	if executionTime > 10 {
-		m.timeIncrement = defaultTimeIncrement
+	if executionTime > resetWindow {
+		m.timeIncrement = initialTimeIncrement(m.restartPolicy)
 	} else {
 		// otherwise we need to increment the amount of time we wait before restarting
-		// the process.  We will build up by multiplying the increment by 2
-		m.timeIncrement *= 2
+		// the process.  We will build up by multiplying the increment by 2 up to
+		// the maximum delay of the policy
+		if m.timeIncrement *= 2; m.timeIncrement > maxTimeIncrement {
+			m.timeIncrement = maxTimeIncrement
+		}
 	}
 
 	// the container exited successfully so we need to reset the failure counter
@@ -198,11 +248,22 @@ func (m *containerMonitor) resetMonitor(successful bool) {
 	}
 }
 
-// waitForNextRestart waits with the default time increment to restart the container unless
+// nextRestartDelay returns the time to wait before the next restart, with the
+// jitter of the policy applied so that containers failing together do not all
+// restart at the same time
+func (m *containerMonitor) nextRestartDelay() time.Duration {
+	delay := m.timeIncrement
+	if jitter := m.restartPolicy.Jitter; jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * jitter * float64(delay))
+	}
+	return delay
+}
+
+// waitForNextRestart waits with the current time increment to restart the container unless
 // a user or docker asks for the container to be stopped
 func (m *containerMonitor) waitForNextRestart() {
 	select {
-	case <-time.After(time.Duration(m.timeIncrement) * time.Millisecond):
+	case <-time.After(m.nextRestartDelay()):
 	case <-m.stopChan:
 	}
 }
@@ -219,7 +280,7 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 	}
 
 	switch m.restartPolicy.Name {
-	case "always":
+	case "always", "unless-stopped":
 		return true
 	case "on-failure":
 		// the default value of 0 for MaximumRetryCount means that we will not enforce a maximum count
@@ -247,6 +308,7 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 	}
 
 	m.container.setRunning(pid)
+	m.container.startHealthcheck()
 
 	// signal that the process has started
 	// close channel only if not closed
@@ -261,6 +323,22 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 	}
 }
 
+// reattachCallback is called once the driver re-attached to the process of
+// the container.  The container is already in the running state, only its
+// health checks need to be started again.
+func (m *containerMonitor) reattachCallback(processConfig *execdriver.ProcessConfig, pid int) {
+	m.container.Lock()
+	m.container.startHealthcheck()
+	m.container.Unlock()
+
+	// close channel only if not closed
+	select {
+	case <-m.startSignal:
+	default:
+		close(m.startSignal)
+	}
+}
+
 // resetContainer resets the container's IO and ensures that the command is able to be executed again
 // by copying the data into a new struct
 // if lock is true, then container locked during reset
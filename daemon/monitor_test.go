@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestMonitorBackoff(t *testing.T) {
+	policy := runconfig.RestartPolicy{
+		Name:         "always",
+		InitialDelay: time.Second,
+		MaximumDelay: 3 * time.Second,
+		ResetWindow:  time.Hour,
+	}
+	m := newContainerMonitor(&Container{}, policy)
+	if m.timeIncrement != time.Second {
+		t.Fatalf("Expected an initial delay of 1s, got %s", m.timeIncrement)
+	}
+
+	m.lastStartTime = time.Now()
+	for _, expected := range []time.Duration{2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		m.resetMonitor(false)
+		if m.timeIncrement != expected {
+			t.Fatalf("Expected a delay of %s, got %s", expected, m.timeIncrement)
+		}
+	}
+
+	// a container running for longer than the reset window starts over
+	m.lastStartTime = time.Now().Add(-2 * time.Hour)
+	m.resetMonitor(false)
+	if m.timeIncrement != time.Second {
+		t.Fatalf("Expected the delay to be reset to 1s, got %s", m.timeIncrement)
+	}
+}
+
+func TestMonitorJitter(t *testing.T) {
+	m := newContainerMonitor(&Container{}, runconfig.RestartPolicy{Name: "always", InitialDelay: time.Second, Jitter: 0.5})
+	for i := 0; i < 100; i++ {
+		if d := m.nextRestartDelay(); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("Delay %s is out of the jitter range", d)
+		}
+	}
+}
@@ -24,6 +24,7 @@ func (daemon *Daemon) ContainerResize(job *engine.Job) engine.Status {
 		if err := container.Resize(height, width); err != nil {
 			return job.Error(err)
 		}
+		container.LogEventAttributes("resize", map[string]string{"height": job.Args[1], "width": job.Args[2]})
 		return engine.StatusOK
 	}
 	return job.Errorf("No such container: %s", name)
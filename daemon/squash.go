@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/dockerversion"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/utils"
+)
+
+// Squash creates an image with the filesystem and the config of the image id
+// in a single layer on top of parent, one of its ancestors. The commands
+// which created the squashed layers are kept in the comment of the new
+// image. The image is returned as is when it has no layer to squash.
+func (daemon *Daemon) Squash(id, parent string) (*image.Image, error) {
+	img, err := daemon.graph.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		history []string
+		found   = parent == ""
+	)
+	if err := img.WalkHistory(func(layer *image.Image) error {
+		if layer.ID == parent {
+			found = true
+			return errSquashDone
+		}
+		history = append(history, strings.Join(layer.ContainerConfig.Cmd, " "))
+		return nil
+	}); err != nil && err != errSquashDone {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Cannot squash %s, %s is not one of its parents", utils.TruncateID(id), utils.TruncateID(parent))
+	}
+	if len(history) < 2 {
+		return img, nil
+	}
+	// oldest first
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	layer, err := daemon.squashedLayer(id, parent)
+	if err != nil {
+		return nil, err
+	}
+	defer layer.Close()
+
+	squashed := &image.Image{
+		ID:              utils.GenerateRandomID(),
+		Parent:          parent,
+		Comment:         strings.Join(history, "\n"),
+		Created:         time.Now().UTC(),
+		Container:       img.Container,
+		ContainerConfig: img.ContainerConfig,
+		DockerVersion:   dockerversion.VERSION,
+		Author:          img.Author,
+		Config:          img.Config,
+		Architecture:    img.Architecture,
+		OS:              img.OS,
+	}
+	// the image must not be a build cache hit for the last squashed command
+	squashed.ContainerConfig.Cmd = []string{"/bin/sh", "-c", fmt.Sprintf("#(squash) %d layers", len(history))}
+
+	if err := daemon.graph.Register(squashed, layer); err != nil {
+		return nil, err
+	}
+	return squashed, nil
+}
+
+var errSquashDone = errors.New("squash: parent found")
+
+// squashedLayer returns an archive of the changes between the filesystems of
+// the images id and parent, which may be "". The graph drivers only diff an
+// image with its direct parent.
+func (daemon *Daemon) squashedLayer(id, parent string) (archive.Archive, error) {
+	layerFs, err := daemon.driver.Get(id, "")
+	if err != nil {
+		return nil, err
+	}
+	layer, err := daemon.exportChanges(layerFs, parent)
+	if err != nil {
+		daemon.driver.Put(id)
+		return nil, err
+	}
+	return ioutils.NewReadCloserWrapper(layer, func() error {
+		err := layer.Close()
+		daemon.driver.Put(id)
+		return err
+	}), nil
+}
+
+// exportChanges returns an archive of the changes of layerFs since the
+// filesystem of the image parent.
+func (daemon *Daemon) exportChanges(layerFs, parent string) (archive.Archive, error) {
+	if parent == "" {
+		return archive.TarWithOptions(layerFs, &archive.TarOptions{
+			Compression: archive.Uncompressed,
+			UIDMaps:     daemon.uidMaps,
+			GIDMaps:     daemon.gidMaps,
+		})
+	}
+	parentFs, err := daemon.driver.Get(parent, "")
+	if err != nil {
+		return nil, err
+	}
+	defer daemon.driver.Put(parent)
+	changes, err := archive.ChangesDirs(layerFs, parentFs)
+	if err != nil {
+		return nil, err
+	}
+	return archive.ExportChanges(layerFs, changes, daemon.uidMaps, daemon.gidMaps)
+}
@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/graph"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
+)
+
+func init() {
+	reexec.Init()
+}
+
+func newSquashTestDaemon(t *testing.T) (*Daemon, func()) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver, err := graphdriver.GetDriver("vfs", tmp, nil, nil, nil)
+	if err != nil {
+		os.RemoveAll(tmp)
+		t.Fatal(err)
+	}
+	g, err := graph.NewGraph(filepath.Join(tmp, "graph"), driver)
+	if err != nil {
+		driver.Cleanup()
+		os.RemoveAll(tmp)
+		t.Fatal(err)
+	}
+	return &Daemon{graph: g, driver: driver}, func() {
+		driver.Cleanup()
+		os.RemoveAll(tmp)
+	}
+}
+
+// registerLayer registers an image whose layer has the given files, created
+// by the given command.
+func registerLayer(t *testing.T, daemon *Daemon, parent, cmd string, files ...string) *image.Image {
+	layer, err := archive.Generate(files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := &image.Image{
+		ID:              utils.GenerateRandomID(),
+		Parent:          parent,
+		ContainerConfig: runconfig.Config{Cmd: []string{"/bin/sh", "-c", cmd}},
+		Config:          &runconfig.Config{Env: []string{"LAYER=" + cmd}},
+	}
+	if err := daemon.graph.Register(img, layer); err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// checkFiles checks which files exist in the filesystem of the image.
+func checkFiles(t *testing.T, daemon *Daemon, id string, present, absent []string) {
+	root, err := daemon.driver.Get(id, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer daemon.driver.Put(id)
+	for _, name := range present {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Fatalf("Expected %s in the squashed image: %s", name, err)
+		}
+	}
+	for _, name := range absent {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("Expected no %s in the squashed image: %v", name, err)
+		}
+	}
+}
+
+func TestSquash(t *testing.T) {
+	daemon, cleanup := newSquashTestDaemon(t)
+	defer cleanup()
+
+	base := registerLayer(t, daemon, "", "base", "base", "")
+	first := registerLayer(t, daemon, base.ID, "add a b", "a", "", "b", "")
+	last := registerLayer(t, daemon, first.ID, "rm a", ".wh.a", "")
+
+	squashed, err := daemon.Squash(last.ID, base.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if squashed.ID == last.ID || squashed.Parent != base.ID {
+		t.Fatalf("Expected a new image on top of %s, got %s on top of %s", base.ID, squashed.ID, squashed.Parent)
+	}
+	if squashed.Comment != "/bin/sh -c add a b\n/bin/sh -c rm a" {
+		t.Fatalf("Expected the squashed commands in the comment, got %q", squashed.Comment)
+	}
+	if len(squashed.Config.Env) != 1 || squashed.Config.Env[0] != "LAYER=rm a" {
+		t.Fatalf("Expected the config of the last image, got %v", squashed.Config.Env)
+	}
+	if cmd := strings.Join(squashed.ContainerConfig.Cmd, " "); cmd != "/bin/sh -c #(squash) 2 layers" {
+		t.Fatalf("Expected the squash command, got %q", cmd)
+	}
+	checkFiles(t, daemon, squashed.ID, []string{"base", "b"}, []string{"a"})
+
+	// a single layer is not squashed
+	img, err := daemon.Squash(first.ID, base.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ID != first.ID {
+		t.Fatalf("Expected the image of a single layer as is, got %s", img.ID)
+	}
+}
+
+func TestSquashWithoutParent(t *testing.T) {
+	daemon, cleanup := newSquashTestDaemon(t)
+	defer cleanup()
+
+	base := registerLayer(t, daemon, "", "base", "base", "")
+	last := registerLayer(t, daemon, base.ID, "add a", "a", "")
+
+	squashed, err := daemon.Squash(last.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if squashed.Parent != "" {
+		t.Fatalf("Expected an image without parent, got %s", squashed.Parent)
+	}
+	if squashed.Comment != "/bin/sh -c base\n/bin/sh -c add a" {
+		t.Fatalf("Expected all the commands in the comment, got %q", squashed.Comment)
+	}
+	checkFiles(t, daemon, squashed.ID, []string{"base", "a"}, nil)
+}
+
+func TestSquashNotAncestor(t *testing.T) {
+	daemon, cleanup := newSquashTestDaemon(t)
+	defer cleanup()
+
+	base := registerLayer(t, daemon, "", "base", "base", "")
+	first := registerLayer(t, daemon, base.ID, "add a", "a", "")
+	last := registerLayer(t, daemon, first.ID, "add b", "b", "")
+	other := registerLayer(t, daemon, "", "other", "other", "")
+
+	if _, err := daemon.Squash(last.ID, other.ID); err == nil || !strings.Contains(err.Error(), "is not one of its parents") {
+		t.Fatalf("Expected an error for a parent which is not an ancestor, got %v", err)
+	}
+}
@@ -3,9 +3,11 @@ package daemon
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -31,6 +33,9 @@ func (daemon *Daemon) ContainerStart(job *engine.Job) engine.Status {
 	// creating a container, not during start.
 	if len(job.Environ()) > 0 {
 		hostConfig := runconfig.ContainerHostConfigFromJob(job)
+		if err := runconfig.ValidateResources(container.Config, hostConfig); err != nil {
+			return job.Error(err)
+		}
 		if err := daemon.setHostConfig(container, hostConfig); err != nil {
 			return job.Error(err)
 		}
@@ -49,6 +54,12 @@ func (daemon *Daemon) setHostConfig(container *Container, hostConfig *runconfig.
 	if err := parseSecurityOpt(container, hostConfig); err != nil {
 		return err
 	}
+	if container.SeccompProfile != "" && container.SeccompProfile != "unconfined" && !daemon.SystemConfig().Seccomp {
+		return fmt.Errorf("Your kernel does not support seccomp, the seccomp profile can't be applied")
+	}
+	if err := daemon.verifyRemappedHostConfig(hostConfig); err != nil {
+		return err
+	}
 	// Validate the HostConfig binds. Make sure that:
 	// the source exists
 	for _, bind := range hostConfig.Binds {
@@ -64,6 +75,18 @@ func (daemon *Daemon) setHostConfig(container *Container, hostConfig *runconfig.
 			}
 		}
 	}
+	// the tmpfs mounts are checked again for the clients of the remote API
+	for path, options := range hostConfig.Tmpfs {
+		if !filepath.IsAbs(path) || filepath.Clean(path) == "/" {
+			return fmt.Errorf("Invalid tmpfs destination %q: it must be an absolute path other than '/'", path)
+		}
+		if _, _, err := mount.ParseTmpfsOptions(options); err != nil {
+			return err
+		}
+	}
+	if err := runconfig.ValidateSysctls(hostConfig.Sysctls, hostConfig.NetworkMode, hostConfig.IpcMode); err != nil {
+		return err
+	}
 	// Register any links from the host config before starting the container
 	if err := daemon.RegisterLinks(container, hostConfig); err != nil {
 		return err
@@ -19,6 +19,7 @@ func (daemon *Daemon) ContainerStop(job *engine.Job) engine.Status {
 		if !container.IsRunning() {
 			return job.Errorf("Container already stopped")
 		}
+		container.setManuallyStopped()
 		if err := container.Stop(int(t)); err != nil {
 			return job.Errorf("Cannot stop container %s: %s\n", name, err)
 		}
@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/pkg/idtools"
+	"github.com/docker/docker/runconfig"
+)
+
+// parseRemappedRoot returns the uid and gid maps of the user[:group] of the
+// --userns-remap option.  The group defaults to the user.
+func parseRemappedRoot(remappedRoot string) ([]idtools.IDMap, []idtools.IDMap, error) {
+	if remappedRoot == "" {
+		return nil, nil, nil
+	}
+	parts := strings.SplitN(remappedRoot, ":", 2)
+	username, groupname := parts[0], parts[0]
+	if len(parts) == 2 {
+		groupname = parts[1]
+	}
+	if username == "" || groupname == "" {
+		return nil, nil, fmt.Errorf("Invalid --userns-remap %q, expected user[:group]", remappedRoot)
+	}
+	uidMaps, gidMaps, err := idtools.CreateIDMappings(username, groupname)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Can't remap the user namespace to %s: %s", remappedRoot, err)
+	}
+	return uidMaps, gidMaps, nil
+}
+
+// GetUIDGIDMaps returns the maps of the container ids to the host ids, nil
+// when the user namespace is not remapped
+func (daemon *Daemon) GetUIDGIDMaps() ([]idtools.IDMap, []idtools.IDMap) {
+	return daemon.uidMaps, daemon.gidMaps
+}
+
+// GetRemappedUIDGID returns the host uid and gid of root in the containers
+func (daemon *Daemon) GetRemappedUIDGID() (int, int) {
+	uid, _ := idtools.ToHost(0, daemon.uidMaps)
+	gid, _ := idtools.ToHost(0, daemon.gidMaps)
+	return uid, gid
+}
+
+// verifyRemappedHostConfig rejects the options which would give the
+// containers access to the host when the user namespace is remapped
+func (daemon *Daemon) verifyRemappedHostConfig(hostConfig *runconfig.HostConfig) error {
+	if daemon.uidMaps == nil || hostConfig == nil {
+		return nil
+	}
+	switch {
+	case hostConfig.Privileged:
+		return fmt.Errorf("Privileged mode is incompatible with the user namespace remapping of the daemon")
+	case hostConfig.NetworkMode.IsHost(), hostConfig.NetworkMode.IsContainer():
+		return fmt.Errorf("--net=%s is incompatible with the user namespace remapping of the daemon", hostConfig.NetworkMode)
+	case hostConfig.IpcMode.IsHost(), hostConfig.IpcMode.IsContainer():
+		return fmt.Errorf("--ipc=%s is incompatible with the user namespace remapping of the daemon", hostConfig.IpcMode)
+	case hostConfig.PidMode.IsHost(), hostConfig.PidMode.IsContainer():
+		return fmt.Errorf("--pid=%s is incompatible with the user namespace remapping of the daemon", hostConfig.PidMode)
+	case hostConfig.UTSMode.IsHost():
+		return fmt.Errorf("--uts=%s is incompatible with the user namespace remapping of the daemon", hostConfig.UTSMode)
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/events"
 	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/volumes"
@@ -61,6 +62,15 @@ func (container *Container) sortedVolumeMounts() []string {
 	return mountPaths
 }
 
+func (container *Container) sortedTmpfsMounts() []string {
+	var mountPaths []string
+	for path := range container.hostConfig.Tmpfs {
+		mountPaths = append(mountPaths, path)
+	}
+	sort.Strings(mountPaths)
+	return mountPaths
+}
+
 func (container *Container) createVolumes() error {
 	mounts, err := container.parseVolumeMountConfig()
 	if err != nil {
@@ -161,6 +171,10 @@ func (container *Container) parseVolumeMountConfig() (map[string]*Mount, error)
 		if err != nil {
 			return nil, err
 		}
+		attrs := map[string]string{"container": container.ID, "destination": path}
+		if err := events.LogEvent(container.daemon.eng, events.VolumeEventType, "create", vol.ID, "", attrs); err != nil {
+			log.Errorf("Error logging volume event for %s: %s", vol.ID, err)
+		}
 		mounts[path] = &Mount{
 			container:   container,
 			MountToPath: path,
@@ -267,6 +281,22 @@ func (container *Container) setupMounts() error {
 		})
 	}
 
+	// The tmpfs mounts are only in the mount namespace of the container, so
+	// that their content is neither written to disk nor committed
+	for _, path := range container.sortedTmpfsMounts() {
+		data := "noexec,nosuid,nodev"
+		if options := container.hostConfig.Tmpfs[path]; options != "" {
+			data += "," + options
+		}
+		mounts = append(mounts, execdriver.Mount{
+			Source:      "tmpfs",
+			Destination: path,
+			Writable:    true,
+			Type:        "tmpfs",
+			Data:        data,
+		})
+	}
+
 	container.command.Mounts = mounts
 	return nil
 }
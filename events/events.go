@@ -2,6 +2,7 @@ package events
 
 import (
 	"encoding/json"
+	"os"
 	"sync"
 	"time"
 
@@ -12,12 +13,22 @@ import (
 
 const eventsLimit = 64
 
+// Types of objects an event can be about.
+const (
+	ContainerEventType = "container"
+	ImageEventType     = "image"
+	VolumeEventType    = "volume"
+	NetworkEventType   = "network"
+	DaemonEventType    = "daemon"
+)
+
 type listener chan<- *utils.JSONMessage
 
 type Events struct {
 	mu          sync.RWMutex
 	events      []*utils.JSONMessage
 	subscribers []listener
+	webhooks    []*webhook
 }
 
 func New() *Events {
@@ -33,6 +44,7 @@ func (e *Events) Install(eng *engine.Engine) error {
 		"events":            e.Get,
 		"log":               e.Log,
 		"subscribers_count": e.SubscribersCount,
+		"events_webhooks":   e.Webhooks,
 	}
 	for name, job := range jobs {
 		if err := eng.Register(name, job); err != nil {
@@ -87,15 +99,42 @@ func (e *Events) Get(job *engine.Job) engine.Status {
 	}
 }
 
+// Log records an event. The optional Type and Attributes environment
+// variables describe the kind of object the event is about and carry
+// action-specific details such as an exit code or a signal.
 func (e *Events) Log(job *engine.Job) engine.Status {
 	if len(job.Args) != 3 {
 		return job.Errorf("usage: %s ACTION ID FROM", job.Name)
 	}
+	jm := &utils.JSONMessage{
+		Status: job.Args[0],
+		ID:     job.Args[1],
+		From:   job.Args[2],
+		Type:   job.Getenv("Type"),
+	}
+	if job.EnvExists("Attributes") {
+		if err := job.GetenvJson("Attributes", &jm.Attributes); err != nil {
+			return job.Error(err)
+		}
+	}
 	// not waiting for receivers
-	go e.log(job.Args[0], job.Args[1], job.Args[2])
+	go e.log(jm)
 	return engine.StatusOK
 }
 
+// LogEvent records an event about an object of the given type through
+// the "log" job of eng.
+func LogEvent(eng *engine.Engine, eventType, action, id, from string, attributes map[string]string) error {
+	job := eng.Job("log", action, id, from)
+	job.Setenv("Type", eventType)
+	if len(attributes) > 0 {
+		if err := job.SetenvJson("Attributes", attributes); err != nil {
+			return err
+		}
+	}
+	return job.Run()
+}
+
 func (e *Events) SubscribersCount(job *engine.Job) engine.Status {
 	ret := &engine.Env{}
 	ret.SetInt("count", e.subscribersCount())
@@ -103,6 +142,56 @@ func (e *Events) SubscribersCount(job *engine.Job) engine.Status {
 	return engine.StatusOK
 }
 
+// Webhooks starts delivering every logged event to the URLs given as
+// arguments. Events waiting for delivery are kept in QueueDir.
+func (e *Events) Webhooks(job *engine.Job) engine.Status {
+	if len(job.Args) == 0 {
+		return job.Errorf("usage: %s URL...", job.Name)
+	}
+	var (
+		queueDir  = job.Getenv("QueueDir")
+		queueSize = job.GetenvInt("QueueSize")
+		batchSize = job.GetenvInt("BatchSize")
+		interval  time.Duration
+	)
+	if queueDir == "" {
+		return job.Errorf("%s: QueueDir is required", job.Name)
+	}
+	if s := job.Getenv("FlushInterval"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return job.Error(err)
+		}
+		interval = d
+	}
+	if err := os.MkdirAll(queueDir, 0700); err != nil {
+		return job.Error(err)
+	}
+
+	hooks := make([]*webhook, 0, len(job.Args))
+	for _, url := range job.Args {
+		w, err := newWebhook(url, queueDir, queueSize, batchSize, interval)
+		if err != nil {
+			return job.Error(err)
+		}
+		hooks = append(hooks, w)
+	}
+
+	e.mu.Lock()
+	for _, w := range hooks {
+		e.webhooks = append(e.webhooks, w)
+		w.start()
+	}
+	e.mu.Unlock()
+
+	job.Eng.OnShutdown(func() {
+		for _, w := range hooks {
+			w.close()
+		}
+	})
+	return engine.StatusOK
+}
+
 func writeEvent(job *engine.Job, event *utils.JSONMessage, eventFilters filters.Args) error {
 	isFiltered := func(field string, filter []string) bool {
 		if len(filter) == 0 {
@@ -116,7 +205,16 @@ func writeEvent(job *engine.Job, event *utils.JSONMessage, eventFilters filters.
 		return true
 	}
 
-	if isFiltered(event.Status, eventFilters["event"]) || isFiltered(event.From, eventFilters["image"]) || isFiltered(event.ID, eventFilters["container"]) {
+	if isFiltered(event.Status, eventFilters["event"]) || isFiltered(event.From, eventFilters["image"]) || isFiltered(event.Type, eventFilters["type"]) {
+		return nil
+	}
+	// Containers can be referred to by ID or by name, and events about
+	// other objects may reference a container through their attributes
+	if isFiltered(event.ID, eventFilters["container"]) && isFiltered(event.Attributes["name"], eventFilters["container"]) && isFiltered(event.Attributes["container"], eventFilters["container"]) {
+		return nil
+	}
+	// Labels of containers and images are part of the event attributes
+	if !eventFilters.MatchKVList("label", event.Attributes) {
 		return nil
 	}
 
@@ -151,10 +249,9 @@ func (e *Events) subscribersCount() int {
 	return c
 }
 
-func (e *Events) log(action, id, from string) {
+func (e *Events) log(jm *utils.JSONMessage) {
 	e.mu.Lock()
-	now := time.Now().UTC().Unix()
-	jm := &utils.JSONMessage{Status: action, ID: id, From: from, Time: now}
+	jm.Time = time.Now().UTC().Unix()
 	if len(e.events) == cap(e.events) {
 		// discard oldest event
 		copy(e.events, e.events[1:])
@@ -170,7 +267,12 @@ func (e *Events) log(action, id, from string) {
 		case <-time.After(100 * time.Millisecond):
 		}
 	}
+	webhooks := e.webhooks
 	e.mu.Unlock()
+
+	for _, w := range webhooks {
+		w.push(jm)
+	}
 }
 
 func (e *Events) subscribe(l listener) {
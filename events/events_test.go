@@ -22,7 +22,7 @@ func TestEventsPublish(t *testing.T) {
 	if count != 2 {
 		t.Fatalf("Must be 2 subscribers, got %d", count)
 	}
-	go e.log("test", "cont", "image")
+	go e.log(&utils.JSONMessage{Status: "test", ID: "cont", From: "image"})
 	select {
 	case msg := <-l1:
 		if len(e.events) != 1 {
@@ -66,7 +66,7 @@ func TestEventsPublishTimeout(t *testing.T) {
 
 	c := make(chan struct{})
 	go func() {
-		e.log("test", "cont", "image")
+		e.log(&utils.JSONMessage{Status: "test", ID: "cont", From: "image"})
 		close(c)
 	}()
 
@@ -152,3 +152,72 @@ func TestEventsCountJob(t *testing.T) {
 		t.Fatalf("There must be 2 subscribers, got %d", count)
 	}
 }
+
+func TestLogEventAttributes(t *testing.T) {
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	l := make(chan *utils.JSONMessage)
+	e.subscribe(l)
+
+	attrs := map[string]string{"name": "web", "exitCode": "1"}
+	if err := LogEvent(eng, ContainerEventType, "die", "cont", "image", attrs); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-l:
+		if msg.Type != ContainerEventType {
+			t.Fatalf("Type should be %s, got %s", ContainerEventType, msg.Type)
+		}
+		if msg.Attributes["exitCode"] != "1" {
+			t.Fatalf("exitCode attribute should be 1, got %s", msg.Attributes["exitCode"])
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for broadcasted message")
+	}
+}
+
+func TestEventsTypeFilter(t *testing.T) {
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+	e.log(&utils.JSONMessage{Status: "start", ID: "cont", From: "image", Type: ContainerEventType, Attributes: map[string]string{"name": "web", "com.example.role": "web"}})
+	e.log(&utils.JSONMessage{Status: "untag", ID: "image", Type: ImageEventType, Attributes: map[string]string{"com.example.role": "db", "com.example.tier": "2"}})
+
+	for filter, expected := range map[string]string{
+		`{"type":["image"]}`:                                     "untag",
+		`{"container":["web"]}`:                                  "start",
+		`{"label":["com.example.role=web"]}`:                     "start",
+		`{"label":["com.example.tier"]}`:                         "untag",
+		`{"label":["com.example.role=db","com.example.tier=2"]}`: "untag",
+	} {
+		job := eng.Job("events")
+		job.SetenvInt64("since", 1)
+		job.SetenvInt64("until", time.Now().Unix())
+		job.Setenv("filters", filter)
+		buf := bytes.NewBuffer(nil)
+		job.Stdout.Add(buf)
+		if err := job.Run(); err != nil {
+			t.Fatal(err)
+		}
+		var msgs []utils.JSONMessage
+		dec := json.NewDecoder(buf)
+		for {
+			var jm utils.JSONMessage
+			if err := dec.Decode(&jm); err != nil {
+				if err == io.EOF {
+					break
+				}
+				t.Fatal(err)
+			}
+			msgs = append(msgs, jm)
+		}
+		if len(msgs) != 1 || msgs[0].Status != expected {
+			t.Fatalf("Filter %s should only match %s, got %v", filter, expected, msgs)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package events
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/docker/docker/utils"
+)
+
+const (
+	defaultWebhookBatchSize = 16
+	defaultWebhookQueueSize = 1024
+	defaultWebhookFlush     = time.Second
+	webhookMaxBackoff       = time.Minute
+	webhookTimeout          = 10 * time.Second
+)
+
+// webhookQueue is a bounded FIFO of events backed by a file, so that
+// events not yet delivered survive a daemon restart. When the queue is
+// full the oldest event is discarded. The changes are written to the file
+// by flush, not by every push and ack.
+type webhookQueue struct {
+	mu     sync.Mutex
+	path   string
+	limit  int
+	events []*utils.JSONMessage
+	dirty  bool
+	notify chan struct{}
+}
+
+func newWebhookQueue(path string, limit int) (*webhookQueue, error) {
+	q := &webhookQueue{
+		path:   path,
+		limit:  limit,
+		notify: make(chan struct{}, 1),
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return q, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &q.events); err != nil {
+		log.Errorf("Discarding corrupted webhook queue %s: %s", path, err)
+		q.events = nil
+	}
+	if len(q.events) > q.limit {
+		q.events = q.events[len(q.events)-q.limit:]
+	}
+	if len(q.events) > 0 {
+		q.signal()
+	}
+	return q, nil
+}
+
+func (q *webhookQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// push appends an event to the queue, dropping the oldest one if the
+// queue is already at its limit.
+func (q *webhookQueue) push(jm *utils.JSONMessage) {
+	q.mu.Lock()
+	if len(q.events) >= q.limit {
+		copy(q.events, q.events[1:])
+		q.events[len(q.events)-1] = jm
+	} else {
+		q.events = append(q.events, jm)
+	}
+	q.dirty = true
+	q.mu.Unlock()
+	q.signal()
+}
+
+// peek returns up to n events from the head of the queue without
+// removing them.
+func (q *webhookQueue) peek(n int) []*utils.JSONMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n > len(q.events) {
+		n = len(q.events)
+	}
+	batch := make([]*utils.JSONMessage, n)
+	copy(batch, q.events[:n])
+	return batch
+}
+
+// ack removes the given events from the head of the queue. Events which
+// were discarded in the meantime because of the queue limit are skipped.
+func (q *webhookQueue) ack(batch []*utils.JSONMessage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, jm := range batch {
+		if len(q.events) == 0 || q.events[0] != jm {
+			continue
+		}
+		q.events = q.events[1:]
+		q.dirty = true
+	}
+}
+
+func (q *webhookQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
+
+// flush writes the queue to its file if it changed since the last flush.
+func (q *webhookQueue) flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.dirty {
+		return nil
+	}
+	if err := q.save(); err != nil {
+		return err
+	}
+	q.dirty = false
+	return nil
+}
+
+// save must be called with q.mu held.
+func (q *webhookQueue) save() error {
+	data, err := json.Marshal(q.events)
+	if err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// webhook delivers events to a remote URL as JSON arrays, batching them
+// and retrying with exponential backoff until the endpoint accepts or
+// permanently rejects them. The events are handed to the webhook through a
+// buffered channel, so that logging an event never waits for the queue
+// file or the endpoint.
+type webhook struct {
+	url           string
+	client        *http.Client
+	queue         *webhookQueue
+	batchSize     int
+	flushInterval time.Duration
+	incoming      chan *utils.JSONMessage
+	stop          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// webhookStatusError is returned by post when the endpoint answers with a
+// non-2xx status code.
+type webhookStatusError struct {
+	code int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.code)
+}
+
+// permanent returns true if sending the same batch again can't succeed,
+// for a client error other than a timeout or rate limiting.
+func (e *webhookStatusError) permanent() bool {
+	return e.code >= 400 && e.code < 500 && e.code != http.StatusRequestTimeout && e.code != http.StatusTooManyRequests
+}
+
+func newWebhook(url, queueDir string, queueSize, batchSize int, flushInterval time.Duration) (*webhook, error) {
+	if queueSize <= 0 {
+		queueSize = defaultWebhookQueueSize
+	}
+	if batchSize <= 0 {
+		batchSize = defaultWebhookBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultWebhookFlush
+	}
+	// Name the queue file after the URL so that a webhook finds its
+	// pending events again whatever the order of the daemon flags.
+	sum := sha256.Sum256([]byte(url))
+	q, err := newWebhookQueue(path.Join(queueDir, hex.EncodeToString(sum[:])+".json"), queueSize)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook{
+		url:           url,
+		client:        &http.Client{Timeout: webhookTimeout},
+		queue:         q,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		incoming:      make(chan *utils.JSONMessage, queueSize),
+		stop:          make(chan struct{}),
+	}, nil
+}
+
+// push hands an event to the webhook without blocking. The event is
+// dropped if the webhook can't keep up.
+func (w *webhook) push(jm *utils.JSONMessage) {
+	select {
+	case w.incoming <- jm:
+	default:
+		log.Errorf("Dropping event %s %s for webhook %s, too many pending events", jm.Status, jm.ID, w.url)
+	}
+}
+
+// start runs the goroutines which queue and deliver the events, until close.
+func (w *webhook) start() {
+	w.wg.Add(2)
+	go w.receive()
+	go w.run()
+}
+
+// receive moves the pushed events to the queue, and writes the queue to
+// its file on every flush interval.
+func (w *webhook) receive() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case jm := <-w.incoming:
+			w.queue.push(jm)
+		case <-ticker.C:
+			w.flush()
+		case <-w.stop:
+			for {
+				select {
+				case jm := <-w.incoming:
+					w.queue.push(jm)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *webhook) flush() {
+	if err := w.queue.flush(); err != nil {
+		log.Errorf("Error persisting queue for webhook %s: %s", w.url, err)
+	}
+}
+
+func (w *webhook) run() {
+	defer w.wg.Done()
+	for {
+		// Wait for at least one event, then give the batch a chance to
+		// fill up before sending it.
+		if w.queue.len() == 0 {
+			select {
+			case <-w.queue.notify:
+			case <-w.stop:
+				return
+			}
+		}
+		if w.queue.len() < w.batchSize {
+			select {
+			case <-time.After(w.flushInterval):
+			case <-w.stop:
+				return
+			}
+		}
+		batch := w.queue.peek(w.batchSize)
+		if len(batch) == 0 {
+			continue
+		}
+		if !w.deliver(batch) {
+			return
+		}
+		w.queue.ack(batch)
+	}
+}
+
+// deliver posts the batch until it succeeds or is permanently rejected,
+// in which case the batch is dropped. It returns false if the webhook was
+// stopped before the batch could be delivered.
+func (w *webhook) deliver(batch []*utils.JSONMessage) bool {
+	backoff := w.flushInterval
+	for {
+		err := w.post(batch)
+		if err == nil {
+			return true
+		}
+		if serr, ok := err.(*webhookStatusError); ok && serr.permanent() {
+			log.Errorf("Dropping %d events rejected by webhook %s: %s", len(batch), w.url, err)
+			return true
+		}
+		log.Debugf("Error posting events to webhook %s, retrying in %s: %s", w.url, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-w.stop:
+			return false
+		}
+		if backoff *= 2; backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+}
+
+func (w *webhook) post(batch []*utils.JSONMessage) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &webhookStatusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+// close stops the webhook and writes the events not yet delivered to the
+// queue file.
+func (w *webhook) close() {
+	close(w.stop)
+	w.wg.Wait()
+	w.flush()
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/utils"
+)
+
+func TestWebhookDelivery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		mu       sync.Mutex
+		failures = 2
+		received []*utils.JSONMessage
+		done     = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []*utils.JSONMessage
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Error decoding webhook body: %s", err)
+		}
+		received = append(received, batch...)
+		if len(received) == 3 {
+			close(done)
+		}
+	}))
+	defer srv.Close()
+
+	w, err := newWebhook(srv.URL, dir, 10, 2, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.webhooks = append(e.webhooks, w)
+	w.start()
+	defer w.close()
+
+	for _, action := range []string{"create", "start", "die"} {
+		e.log(&utils.JSONMessage{Status: action, ID: "cont", From: "image"})
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for webhook delivery")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, action := range []string{"create", "start", "die"} {
+		if received[i].Status != action {
+			t.Fatalf("Event %d should be %s, got %s", i, action, received[i].Status)
+		}
+	}
+}
+
+func TestWebhookQueuePersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-queue-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := dir + "/queue.json"
+	q, err := newWebhookQueue(p, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []string{"create", "start", "die"} {
+		q.push(&utils.JSONMessage{Status: action})
+	}
+	if err := q.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = newWebhookQueue(p, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := q.peek(10)
+	if len(batch) != 2 {
+		t.Fatalf("Queue should hold 2 events, got %d", len(batch))
+	}
+	if batch[0].Status != "start" || batch[1].Status != "die" {
+		t.Fatalf("Oldest event should have been dropped, got %s and %s", batch[0].Status, batch[1].Status)
+	}
+	q.ack(batch[:1])
+	if err := q.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = newWebhookQueue(p, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := q.len(); n != 1 {
+		t.Fatalf("Queue should hold 1 event, got %d", n)
+	}
+}
+
+func TestWebhookPermanentFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		mu       sync.Mutex
+		requests int
+		received []*utils.JSONMessage
+		done     = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var batch []*utils.JSONMessage
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Error decoding webhook body: %s", err)
+		}
+		received = append(received, batch...)
+		close(done)
+	}))
+	defer srv.Close()
+
+	w, err := newWebhook(srv.URL, dir, 10, 1, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.start()
+	defer w.close()
+
+	// with batches of one event, the rejected batch must be dropped rather
+	// than retried before the next one
+	w.push(&utils.JSONMessage{Status: "create", ID: "cont", From: "image"})
+	w.push(&utils.JSONMessage{Status: "start", ID: "cont", From: "image"})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for webhook delivery")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 2 || len(received) != 1 || received[0].Status != "start" {
+		t.Fatalf("The batch rejected with 400 should be dropped, got %d requests and %v", requests, received)
+	}
+}
@@ -0,0 +1,92 @@
+package graph
+
+import (
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/runconfig"
+)
+
+// The build cache index maps the cache keys of the images (see
+// runconfig.CacheKey) to their IDs, so that the builder finds its cache
+// without walking the graph at every step. It is built from the graph the
+// first time it is used and then kept up to date as images are registered,
+// the images deleted since are dropped when they are looked up.
+
+// GetCached returns the most recent image committed on top of parent from a
+// container with config, or nil if there is none. When allowed is not nil,
+// only the images it contains are used.
+func (graph *Graph) GetCached(parent string, config *runconfig.Config, allowed map[string]struct{}) (*image.Image, error) {
+	key := runconfig.CacheKey(parent, config)
+	if key == "" {
+		return nil, nil
+	}
+
+	graph.cacheLock.Lock()
+	if graph.cacheIndex == nil {
+		graph.cacheIndex = map[string]map[string]struct{}{}
+		if err := graph.walkAll(graph.indexImage); err != nil {
+			graph.cacheIndex = nil
+			graph.cacheLock.Unlock()
+			return nil, err
+		}
+	}
+	ids := []string{}
+	for id := range graph.cacheIndex[key] {
+		ids = append(ids, id)
+	}
+	graph.cacheLock.Unlock()
+
+	var match *image.Image
+	for _, id := range ids {
+		if allowed != nil {
+			if _, ok := allowed[id]; !ok {
+				continue
+			}
+		}
+		img, err := graph.Get(id)
+		if err != nil {
+			if graph.IsNotExist(err) {
+				graph.unindexImage(key, id)
+				continue
+			}
+			return nil, err
+		}
+		// don't trust the key alone
+		if img.Parent != parent || !runconfig.Compare(&img.ContainerConfig, config) {
+			continue
+		}
+		if match == nil || match.Created.Before(img.Created) {
+			match = img
+		}
+	}
+	return match, nil
+}
+
+// cacheImage adds a new image to the build cache index, if it is built.
+func (graph *Graph) cacheImage(img *image.Image) {
+	graph.cacheLock.Lock()
+	defer graph.cacheLock.Unlock()
+	if graph.cacheIndex != nil {
+		graph.indexImage(img)
+	}
+}
+
+// indexImage adds an image to the build cache index, the caller holds cacheLock.
+func (graph *Graph) indexImage(img *image.Image) {
+	key := runconfig.CacheKey(img.Parent, &img.ContainerConfig)
+	if key == "" {
+		return
+	}
+	if _, exists := graph.cacheIndex[key]; !exists {
+		graph.cacheIndex[key] = map[string]struct{}{}
+	}
+	graph.cacheIndex[key][img.ID] = struct{}{}
+}
+
+func (graph *Graph) unindexImage(key, id string) {
+	graph.cacheLock.Lock()
+	defer graph.cacheLock.Unlock()
+	delete(graph.cacheIndex[key], id)
+	if len(graph.cacheIndex[key]) == 0 {
+		delete(graph.cacheIndex, key)
+	}
+}
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
+)
+
+func TestGetCached(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	driver, err := graphdriver.New(tmp, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Cleanup()
+	graph, err := NewGraph(tmp, driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	register := func(id, parent string, created time.Time, config runconfig.Config) {
+		archive, err := fakeTar()
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := &image.Image{ID: id, Parent: parent, Created: created, ContainerConfig: config}
+		if err := graph.Register(img, archive); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := runconfig.Config{Cmd: []string{"/bin/sh", "-c", "make"}}
+	now := time.Now()
+
+	register(testImageID, "", now, runconfig.Config{})
+	// this one is indexed when the index is built
+	register("1111111111111111111111111111111111111111111111111111111111111111", testImageID, now, run)
+
+	if img, err := graph.GetCached(testImageID, &run, nil); err != nil {
+		t.Fatal(err)
+	} else if img == nil || img.ID != "1111111111111111111111111111111111111111111111111111111111111111" {
+		t.Fatalf("Expected the cached image, got %v", img)
+	}
+
+	// this one is indexed when it is registered, and is the most recent
+	register("2222222222222222222222222222222222222222222222222222222222222222", testImageID, now.Add(time.Second), run)
+	if img, err := graph.GetCached(testImageID, &run, nil); err != nil {
+		t.Fatal(err)
+	} else if img == nil || img.ID != "2222222222222222222222222222222222222222222222222222222222222222" {
+		t.Fatalf("Expected the most recent cached image, got %v", img)
+	}
+
+	// only the allowed images are used
+	allowed := map[string]struct{}{"1111111111111111111111111111111111111111111111111111111111111111": {}}
+	if img, err := graph.GetCached(testImageID, &run, allowed); err != nil {
+		t.Fatal(err)
+	} else if img == nil || img.ID != "1111111111111111111111111111111111111111111111111111111111111111" {
+		t.Fatalf("Expected the allowed cached image, got %v", img)
+	}
+
+	other := runconfig.Config{Cmd: []string{"/bin/sh", "-c", "make install"}}
+	if img, err := graph.GetCached(testImageID, &other, nil); err != nil {
+		t.Fatal(err)
+	} else if img != nil {
+		t.Fatalf("Expected no cached image for another config, got %s", img.ID)
+	}
+
+	if err := graph.Delete("2222222222222222222222222222222222222222222222222222222222222222"); err != nil {
+		t.Fatal(err)
+	}
+	if img, err := graph.GetCached(testImageID, &run, nil); err != nil {
+		t.Fatal(err)
+	} else if img == nil || img.ID != "1111111111111111111111111111111111111111111111111111111111111111" {
+		t.Fatalf("Expected the remaining cached image, got %v", img)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/docker/docker/dockerversion"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/truncindex"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
@@ -27,6 +29,9 @@ type Graph struct {
 	Root    string
 	idIndex *truncindex.TruncIndex
 	driver  graphdriver.Driver
+
+	cacheLock  sync.Mutex
+	cacheIndex map[string]map[string]struct{} // build cache key -> image IDs, see buildcache.go
 }
 
 // NewGraph instantiates a new graph at the given root path in the filesystem.
@@ -189,6 +194,7 @@ func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader)
 		return err
 	}
 	graph.idIndex.Add(img.ID)
+	graph.cacheImage(img)
 	return nil
 }
 
@@ -229,7 +235,8 @@ func (graph *Graph) Mktemp(id string) (string, error) {
 //
 // This extra layer is used by all containers as the top-most ro layer. It protects
 // the container from unwanted side-effects on the rw layer.
-func SetupInitLayer(initLayer string) error {
+// The mountpoints are owned by rootUID and rootGID, root in the container.
+func SetupInitLayer(initLayer string, rootUID, rootGID int) error {
 	for pth, typ := range map[string]string{
 		"/dev/pts":         "dir",
 		"/dev/shm":         "dir",
@@ -252,12 +259,12 @@ func SetupInitLayer(initLayer string) error {
 
 		if _, err := os.Stat(path.Join(initLayer, pth)); err != nil {
 			if os.IsNotExist(err) {
-				if err := os.MkdirAll(path.Join(initLayer, path.Dir(pth)), 0755); err != nil {
+				if err := idtools.MkdirAllAs(path.Join(initLayer, path.Dir(pth)), 0755, rootUID, rootGID); err != nil {
 					return err
 				}
 				switch typ {
 				case "dir":
-					if err := os.MkdirAll(path.Join(initLayer, pth), 0755); err != nil {
+					if err := idtools.MkdirAllAs(path.Join(initLayer, pth), 0755, rootUID, rootGID); err != nil {
 						return err
 					}
 				case "file":
@@ -265,7 +272,11 @@ func SetupInitLayer(initLayer string) error {
 					if err != nil {
 						return err
 					}
+					err = f.Chown(rootUID, rootGID)
 					f.Close()
+					if err != nil {
+						return err
+					}
 				default:
 					if err := os.Symlink(typ, path.Join(initLayer, pth)); err != nil {
 						return err
@@ -6,6 +6,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/events"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/utils"
 )
@@ -62,7 +63,7 @@ func (s *TagStore) CmdImport(job *engine.Job) engine.Status {
 	if tag != "" {
 		logID += ":" + tag
 	}
-	if err = job.Eng.Job("log", "import", logID, "").Run(); err != nil {
+	if err = events.LogEvent(job.Eng, events.ImageEventType, "import", logID, "", nil); err != nil {
 		log.Errorf("Error logging event 'import' for %s: %s", logID, err)
 	}
 	return engine.StatusOK
@@ -60,7 +60,7 @@ func (s *TagStore) CmdImages(job *engine.Job) engine.Status {
 			} else {
 				// get the boolean list for if only the untagged images are requested
 				delete(allImages, id)
-				if filt_tagged {
+				if filt_tagged && imageFilters.MatchKVList("label", imageLabels(image)) {
 					out := &engine.Env{}
 					out.Set("ParentId", image.Parent)
 					out.SetList("RepoTags", []string{fmt.Sprintf("%s:%s", name, tag)})
@@ -68,6 +68,7 @@ func (s *TagStore) CmdImages(job *engine.Job) engine.Status {
 					out.SetInt64("Created", image.Created.Unix())
 					out.SetInt64("Size", image.Size)
 					out.SetInt64("VirtualSize", image.GetParentsSize(0)+image.Size)
+					out.SetJson("Labels", imageLabels(image))
 					lookup[id] = out
 				}
 			}
@@ -84,6 +85,9 @@ func (s *TagStore) CmdImages(job *engine.Job) engine.Status {
 	// Display images which aren't part of a repository/tag
 	if job.Getenv("filter") == "" {
 		for _, image := range allImages {
+			if !imageFilters.MatchKVList("label", imageLabels(image)) {
+				continue
+			}
 			out := &engine.Env{}
 			out.Set("ParentId", image.Parent)
 			out.SetList("RepoTags", []string{"<none>:<none>"})
@@ -91,6 +95,7 @@ func (s *TagStore) CmdImages(job *engine.Job) engine.Status {
 			out.SetInt64("Created", image.Created.Unix())
 			out.SetInt64("Size", image.Size)
 			out.SetInt64("VirtualSize", image.GetParentsSize(0)+image.Size)
+			out.SetJson("Labels", imageLabels(image))
 			outs.Add(out)
 		}
 	}
@@ -101,3 +106,11 @@ func (s *TagStore) CmdImages(job *engine.Job) engine.Status {
 	}
 	return engine.StatusOK
 }
+
+// imageLabels returns the labels set on img, if any.
+func imageLabels(img *image.Image) map[string]string {
+	if img.Config == nil {
+		return nil
+	}
+	return img.Config.Labels
+}
@@ -14,6 +14,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/events"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
@@ -149,7 +150,7 @@ func (s *TagStore) CmdPull(job *engine.Job) engine.Status {
 		}
 
 		if err := s.pullV2Repository(job.Eng, r, job.Stdout, localName, remoteName, tag, sf, job.GetenvBool("parallel")); err == nil {
-			if err = job.Eng.Job("log", "pull", logName, "").Run(); err != nil {
+			if err = events.LogEvent(job.Eng, events.ImageEventType, "pull", logName, "", nil); err != nil {
 				log.Errorf("Error logging event 'pull' for %s: %s", logName, err)
 			}
 			return engine.StatusOK
@@ -162,7 +163,7 @@ func (s *TagStore) CmdPull(job *engine.Job) engine.Status {
 		return job.Error(err)
 	}
 
-	if err = job.Eng.Job("log", "pull", logName, "").Run(); err != nil {
+	if err = events.LogEvent(job.Eng, events.ImageEventType, "pull", logName, "", nil); err != nil {
 		log.Errorf("Error logging event 'pull' for %s: %s", logName, err)
 	}
 
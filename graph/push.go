@@ -9,6 +9,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/events"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
@@ -236,6 +237,7 @@ func (s *TagStore) CmdPush(job *engine.Job) engine.Status {
 			if err := s.pushRepository(r, job.Stdout, localName, remoteName, localRepo, tag, sf); err != nil {
 				return job.Error(err)
 			}
+			s.logPushEvent(job.Eng, localName, tag)
 			return engine.StatusOK
 		}
 		return job.Error(err)
@@ -246,5 +248,16 @@ func (s *TagStore) CmdPush(job *engine.Job) engine.Status {
 	if _, err := s.pushImage(r, job.Stdout, remoteName, img.ID, endpoint.String(), token, sf); err != nil {
 		return job.Error(err)
 	}
+	s.logPushEvent(job.Eng, localName, "")
 	return engine.StatusOK
 }
+
+func (s *TagStore) logPushEvent(eng *engine.Engine, localName, tag string) {
+	logName := localName
+	if tag != "" {
+		logName += ":" + tag
+	}
+	if err := events.LogEvent(eng, events.ImageEventType, "push", logName, "", nil); err != nil {
+		log.Errorf("Error logging event 'push' for %s: %s", logName, err)
+	}
+}
@@ -1,7 +1,10 @@
 package graph
 
 import (
+	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/events"
+	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/parsers"
 )
 
@@ -25,6 +28,7 @@ func (s *TagStore) CmdTag(job *engine.Job) engine.Status {
 	if err := s.Set(newRepo, newTag, oldName, true); err != nil {
 		return job.Error(err)
 	}
+	s.logTagEvent(job.Eng, oldName, newRepo, newTag)
 	return engine.StatusOK
 }
 
@@ -40,5 +44,33 @@ func (s *TagStore) CmdTagLegacy(job *engine.Job) engine.Status {
 	if err := s.Set(job.Args[1], tag, job.Args[0], job.GetenvBool("force")); err != nil {
 		return job.Error(err)
 	}
+	s.logTagEvent(job.Eng, job.Args[0], job.Args[1], tag)
 	return engine.StatusOK
 }
+
+func (s *TagStore) logTagEvent(eng *engine.Engine, imageName, repoName, tag string) {
+	img, err := s.LookupImage(imageName)
+	if err != nil || img == nil {
+		return
+	}
+	if tag == "" {
+		tag = DEFAULTTAG
+	}
+	attrs := ImageEventAttributes(img, map[string]string{"name": repoName + ":" + tag})
+	if err := events.LogEvent(eng, events.ImageEventType, "tag", img.ID, "", attrs); err != nil {
+		log.Errorf("Error logging event 'tag' for %s: %s", img.ID, err)
+	}
+}
+
+// ImageEventAttributes returns the attributes of an event about img: its
+// labels, overridden by the action-specific attributes.
+func ImageEventAttributes(img *image.Image, attributes map[string]string) map[string]string {
+	attrs := make(map[string]string)
+	for k, v := range imageLabels(img) {
+		attrs[k] = v
+	}
+	for k, v := range attributes {
+		attrs[k] = v
+	}
+	return attrs
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/utils"
+)
+
+// buildSteps builds the Dockerfile with the API, and returns the step
+// events and the text of the other messages.
+func buildSteps(t *testing.T, name, dockerfile string) ([]*utils.JSONStep, string) {
+	context, err := archive.Generate("Dockerfile", dockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := sockRequestRaw("POST", "/build?steps=1&t="+name, context, "application/tar")
+	if err != nil {
+		t.Fatalf("failed to build: %s, %v", body, err)
+	}
+	var (
+		steps  []*utils.JSONStep
+		stream string
+	)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var jm utils.JSONMessage
+		if err := dec.Decode(&jm); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if jm.Error != nil {
+			t.Fatal(jm.Error)
+		}
+		if jm.Step != nil {
+			steps = append(steps, jm.Step)
+		}
+		stream += jm.Stream
+	}
+	return steps, stream
+}
+
+func TestBuildApiSteps(t *testing.T) {
+	name := "testbuildapisteps"
+	defer deleteImages(name)
+	build := func() []*utils.JSONStep {
+		steps, _ := buildSteps(t, name, "FROM busybox\nRUN echo hello-steps")
+		return steps
+	}
+
+	done := map[int]*utils.JSONStep{}
+	output := ""
+	for _, step := range build() {
+		switch step.Event {
+		case utils.StepDone:
+			done[step.Index] = step
+		case utils.StepOutput:
+			output += step.Output
+		}
+	}
+	if len(done) != 2 || done[1] == nil || done[1].Instruction != "RUN echo hello-steps" {
+		t.Fatalf("expected the done events of the 2 steps, got %v", done)
+	}
+	if done[1].Cached || done[1].ContainerID == "" || done[1].ImageID == "" || done[1].Duration <= 0 {
+		t.Fatalf("expected a step run in a container, got %+v", done[1])
+	}
+	if !strings.Contains(output, "hello-steps") {
+		t.Fatalf("expected the output of the step, got %q", output)
+	}
+
+	id := done[1].ImageID
+	for _, step := range build() {
+		if step.Event == utils.StepDone && step.Index == 1 {
+			if !step.Cached || step.ImageID != id {
+				t.Fatalf("expected the cached image %s, got %+v", id, step)
+			}
+			logDone("build API - build steps as JSON events")
+			return
+		}
+	}
+	t.Fatal("expected the done event of the cached step")
+}
+
+func TestBuildApiStepsTrigger(t *testing.T) {
+	parent := "testbuildapistepstriggerparent"
+	name := "testbuildapistepstrigger"
+	defer deleteImages(name, parent)
+	if _, err := buildImage(parent, "FROM busybox\nONBUILD RUN echo hello-trigger", true); err != nil {
+		t.Fatal(err)
+	}
+
+	steps, stream := buildSteps(t, name, "FROM "+parent)
+	var trigger *utils.JSONStep
+	output := ""
+	for _, step := range steps {
+		switch {
+		case step.Event == utils.StepStart && step.Trigger:
+			trigger = step
+		case step.Event == utils.StepOutput && step.Index == 0:
+			output += step.Output
+		}
+	}
+	if trigger == nil || trigger.Index != 0 || trigger.Instruction != "RUN echo hello-trigger" {
+		t.Fatalf("expected the start event of the trigger in step 0, got %v", steps)
+	}
+	if !strings.Contains(output, "hello-trigger") {
+		t.Fatalf("expected the output of the trigger in step 0, got %q", output)
+	}
+	if strings.Contains(stream, "Trigger 0") || strings.Contains(stream, "Step 0") {
+		t.Fatalf("expected the trigger to be sent as an event only, got %q", stream)
+	}
+	logDone("build API - ONBUILD triggers as JSON step events")
+}
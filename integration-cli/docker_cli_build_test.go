@@ -1849,6 +1849,258 @@ func TestBuildEnv(t *testing.T) {
 	logDone("build - env")
 }
 
+func TestBuildLabels(t *testing.T) {
+	name := "testbuildlabel"
+	expected := `{"com.example.bar":"baz","com.example.foo":"bar value","com.example.inherited":""}`
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox
+		ENV BAR baz
+		LABEL com.example.inherited=""
+		LABEL com.example.foo="bar value" com.example.bar=$BAR`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := inspectFieldJSON(name, "Config.Labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != expected {
+		t.Fatalf("Labels %s, expected %s", res, expected)
+	}
+	logDone("build - label")
+}
+
+func TestBuildArg(t *testing.T) {
+	name := "testbuildarg"
+	expected := "[PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin]"
+	defer deleteImages(name)
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--build-arg", "FOO=bar", "-")
+	buildCmd.Stdin = strings.NewReader(`FROM busybox
+		ARG FOO
+		ARG USER=root
+		RUN [ "$FOO" = bar ] && [ "$USER" = root ]
+		WORKDIR /$FOO`)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("failed to build the image: %s, %v", out, err)
+	}
+	res, err := inspectField(name, "Config.Env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != expected {
+		t.Fatalf("Env %s, expected %s", res, expected)
+	}
+	res, err = inspectField(name, "Config.WorkingDir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "/bar" {
+		t.Fatalf("WorkingDir %s, expected /bar", res)
+	}
+	logDone("build - arg")
+}
+
+func TestBuildArgNotDeclared(t *testing.T) {
+	name := "testbuildargnotdeclared"
+	defer deleteImages(name)
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--build-arg", "FOO=bar", "-")
+	buildCmd.Stdin = strings.NewReader(`FROM busybox
+		RUN true`)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err == nil {
+		t.Fatalf("build with an undeclared build-arg should fail: %s", out)
+	}
+	if !strings.Contains(out, "were not consumed") {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	logDone("build - arg not declared")
+}
+
+func TestBuildMultiStage(t *testing.T) {
+	name := "testbuildmultistage"
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox AS first
+		RUN echo -n first > /first && echo -n leftover > /leftover
+		FROM busybox AS second
+		COPY --from=first /first /second
+		FROM busybox
+		COPY --from=second /second /result
+		COPY --from=0 /first /`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCmd := exec.Command(dockerBinary, "run", "--rm", name, "sh", "-c", "cat /result /first && [ ! -e /leftover ]")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to check the result of the build: %s, %v", out, err)
+	}
+	if out != "firstfirst" {
+		t.Fatalf("expected the files copied from the stages, got %q", out)
+	}
+	logDone("build - multi-stage with COPY --from")
+}
+
+func TestBuildMultiStageTarget(t *testing.T) {
+	name := "testbuildmultistagetarget"
+	defer deleteImages(name)
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--target", "build", "-")
+	buildCmd.Stdin = strings.NewReader(`FROM busybox AS build
+		ENV STAGE build
+		FROM busybox
+		RUN false`)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("failed to build the target stage: %s, %v", out, err)
+	}
+	res, err := inspectField(name, "Config.Env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res, "STAGE=build") {
+		t.Fatalf("expected the image of the build stage, got Env %s", res)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "-t", name, "--target", "missing", "-")
+	buildCmd.Stdin = strings.NewReader(`FROM busybox AS build`)
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err == nil || !strings.Contains(out, "could not be found") {
+		t.Fatalf("build with a missing target stage should fail: %s", out)
+	}
+	logDone("build - multi-stage with --target")
+}
+
+func TestBuildCacheFrom(t *testing.T) {
+	name := "testbuildcachefrom"
+	other := "testbuildcachefromother"
+	defer deleteImages(name, other)
+	dockerfile := `FROM busybox
+		RUN echo cachefrom > /cachefrom`
+	if _, err := buildImage(name, dockerfile, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildImage(other, "FROM busybox", true); err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(cacheFrom string) string {
+		buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--cache-from", cacheFrom, "-")
+		buildCmd.Stdin = strings.NewReader(dockerfile)
+		out, _, err := runCommandWithOutput(buildCmd)
+		if err != nil {
+			t.Fatalf("failed to build the image: %s, %v", out, err)
+		}
+		return out
+	}
+	if out := build(name); !strings.Contains(out, "Using cache") {
+		t.Fatalf("expected the image given with --cache-from to be used as cache: %s", out)
+	}
+	if out := build(other); strings.Contains(out, "Using cache") {
+		t.Fatalf("expected only the images given with --cache-from to be used as cache: %s", out)
+	}
+	logDone("build - cache-from")
+}
+
+func TestBuildSquash(t *testing.T) {
+	name := "testbuildsquash"
+	defer deleteImages(name)
+	defer deleteAllContainers()
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--squash", "-")
+	buildCmd.Stdin = strings.NewReader(`FROM busybox
+		RUN echo a > /a && echo b > /b
+		RUN rm /a
+		ENV SQUASHED yes`)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("failed to build the image: %s, %v", out, err)
+	}
+
+	busybox, err := getIDByName("busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := inspectField(name, "Parent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent != busybox {
+		t.Fatalf("expected the squashed image on top of busybox %s, got %s", busybox, parent)
+	}
+	env, err := inspectField(name, "Config.Env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(env, "SQUASHED=yes") {
+		t.Fatalf("expected the config of the last step, got Env %s", env)
+	}
+	comment, err := inspectField(name, "Comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(comment, "echo a > /a") || !strings.Contains(comment, "rm /a") {
+		t.Fatalf("expected the squashed commands in the comment, got %q", comment)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--rm", name, "sh", "-c", "[ ! -e /a ] && cat /b"))
+	if err != nil {
+		t.Fatalf("expected /a removed and /b kept in the squashed layer: %s, %v", out, err)
+	}
+	if strings.TrimSpace(out) != "b" {
+		t.Fatalf("expected b, got %q", out)
+	}
+	logDone("build - squash")
+}
+
+func TestBuildCheck(t *testing.T) {
+	name := "testbuildcheck"
+	defer deleteImages(name)
+	check := func(dockerfile string) (string, error) {
+		buildCmd := exec.Command(dockerBinary, "build", "-t", name, "--check", "-")
+		buildCmd.Stdin = strings.NewReader(dockerfile)
+		out, _, err := runCommandWithOutput(buildCmd)
+		return out, err
+	}
+
+	out, err := check(`FROM busybox
+		RUN apt-get update
+		ADD foo /foo`)
+	if err == nil {
+		t.Fatalf("expected the check to fail: %s", out)
+	}
+	for _, issue := range []string{"line 1: FROM busybox has no tag", "line 2: apt-get update", "line 3: ADD copies local files", "Found 3 issue(s)"} {
+		if !strings.Contains(out, issue) {
+			t.Fatalf("expected %q in the output: %s", issue, out)
+		}
+	}
+	if _, err := getIDByName(name); err == nil {
+		t.Fatal("expected no image to be built by the check")
+	}
+
+	if out, err := check("FROM busybox:latest\nCOPY foo /foo"); err != nil || !strings.Contains(out, "No issues found") {
+		t.Fatalf("expected no issues: %s, %v", out, err)
+	}
+	logDone("build - check")
+}
+
+func TestBuildErrorLines(t *testing.T) {
+	name := "testbuilderrorlines"
+	defer deleteImages(name)
+	_, err := buildImage(name, `FROM busybox
+
+		RUN echo error \
+		  && exit 23`, true)
+	if err == nil || !strings.Contains(err.Error(), "Dockerfile lines 3-4: ") || !strings.Contains(err.Error(), "returned a non-zero code: 23") {
+		t.Fatalf("expected the lines of the failed RUN in the error: %v", err)
+	}
+
+	_, err = buildImage(name, "FROM busybox\nENV FOO", true)
+	if err == nil || !strings.Contains(err.Error(), "Dockerfile line 2: ENV must have two arguments") {
+		t.Fatalf("expected the line of the parse error: %v", err)
+	}
+	logDone("build - errors with the lines of the Dockerfile")
+}
+
 func TestBuildContextCleanup(t *testing.T) {
 	name := "testbuildcontextcleanup"
 	defer deleteImages(name)
@@ -2987,28 +3239,261 @@ func TestBuildDockerignoringDockerfile(t *testing.T) {
 	name := "testbuilddockerignoredockerfile"
 	defer deleteImages(name)
 	dockerfile := `
-        FROM scratch`
+        FROM busybox
+        COPY . /ctx/
+        RUN [[ ! -e /ctx/Dockerfile ]] && [[ ! -e /ctx/.dockerignore ]] && [[ -f /ctx/foo ]]`
 	ctx, err := fakeContext(dockerfile, map[string]string{
-		"Dockerfile":    "FROM scratch",
-		".dockerignore": "Dockerfile\n",
+		"foo":           "foo",
+		".dockerignore": "Dockerfile\n.dockerignore\n",
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer ctx.Close()
-	if _, err = buildImageFromContext(name, ctx, true); err == nil {
-		t.Fatalf("Didn't get expected error from ignoring Dockerfile")
+	if _, err = buildImageFromContext(name, ctx, true); err != nil {
+		t.Fatalf("Failed to build with the Dockerfile ignored: %s", err)
 	}
 
 	// now try it with ./Dockerfile
-	ctx.Add(".dockerignore", "./Dockerfile\n")
-	if _, err = buildImageFromContext(name, ctx, true); err == nil {
-		t.Fatalf("Didn't get expected error from ignoring ./Dockerfile")
+	ctx.Add(".dockerignore", "./Dockerfile\n./.dockerignore\n")
+	if _, err = buildImageFromContext(name, ctx, true); err != nil {
+		t.Fatalf("Failed to build with ./Dockerfile ignored: %s", err)
 	}
 
 	logDone("build - test .dockerignore of Dockerfile")
 }
 
+func TestBuildDockerignoreExceptions(t *testing.T) {
+	name := "testbuilddockerignoreexceptions"
+	defer deleteImages(name)
+	dockerfile := `
+        FROM busybox
+        COPY . /ctx/
+        RUN [[ -f /ctx/docs/README.md ]] && [[ ! -e /ctx/docs/index.md ]]
+        RUN [[ ! -e /ctx/src/a/b/notes.txt ]] && [[ -f /ctx/src/a/b/main.go ]]`
+	ctx, err := fakeContext(dockerfile, map[string]string{
+		"docs/README.md":    "readme",
+		"docs/index.md":     "index",
+		"src/a/b/notes.txt": "notes",
+		"src/a/b/main.go":   "package main",
+		".dockerignore":     "docs\n!docs/README.md\n**/*.txt\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	if _, err := buildImageFromContext(name, ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	logDone("build - test .dockerignore exceptions and ** patterns")
+}
+
+func TestBuildDockerignoreTarContext(t *testing.T) {
+	name := "testbuilddockerignoretarcontext"
+	defer deleteImages(name)
+	dockerfile := `
+        FROM busybox
+        COPY . /ctx/
+        RUN [[ -f /ctx/Makefile ]] && [[ ! -e /ctx/secret ]] && [[ ! -e /ctx/.git ]]`
+	ctx, err := fakeContext(dockerfile, map[string]string{
+		"Makefile":      "all:",
+		"secret":        "secret",
+		".git/HEAD":     "ref: foo",
+		".dockerignore": "secret\n.git\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	// the client sends a tar context as is, the daemon applies .dockerignore
+	context, err := archive.Tar(ctx.Dir, archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer context.Close()
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "-")
+	buildCmd.Stdin = context
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("failed to build the image: %s, %v", out, err)
+	}
+	logDone("build - test .dockerignore applied by the daemon")
+}
+
+func TestBuildDockerfileFlag(t *testing.T) {
+	name := "testbuilddockerfileflag"
+	defer deleteImages(name)
+	ctx, err := fakeContext("FROM busybox\nRUN false", map[string]string{
+		"foo": "foo",
+		"sub/Dockerfile.other": `FROM busybox
+		ADD . /ctx
+		RUN [ -f /ctx/foo ]`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "-f", "sub/Dockerfile.other", ".")
+	buildCmd.Dir = ctx.Dir
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("failed to build with -f: %s, %v", out, err)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "-t", name, "-f", "sub/missing", ".")
+	buildCmd.Dir = ctx.Dir
+	out, _, err := runCommandWithOutput(buildCmd)
+	if err == nil || !strings.Contains(out, "Cannot locate specified Dockerfile: sub/missing") {
+		t.Fatalf("build with a missing Dockerfile should fail: %s", out)
+	}
+	logDone("build - -f with a Dockerfile in the context")
+}
+
+func TestBuildDockerfileOutsideContext(t *testing.T) {
+	name := "testbuilddockerfileoutsidecontext"
+	defer deleteImages(name)
+	ctx, err := fakeContext("FROM busybox\nRUN false", map[string]string{
+		"foo": "foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	dir, err := ioutil.TempDir("", "dockerfile-outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the Dockerfile and the .dockerignore added with it must not be in the context
+	dockerfile := `FROM busybox
+		ADD . /ctx
+		RUN [ -f /ctx/foo ] && [ ! -e /ctx/.dockerignore ] && [ -z "$(ls -A /ctx | grep dockerfile)" ]`
+	if err := ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	buildCmd := exec.Command(dockerBinary, "build", "-t", name, "-f", filepath.Join(dir, "Dockerfile"), ctx.Dir)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("failed to build with a Dockerfile out of the context: %s, %v", out, err)
+	}
+
+	buildCmd = exec.Command(dockerBinary, "build", "-t", name, "-f", "-", ctx.Dir)
+	buildCmd.Stdin = strings.NewReader(dockerfile)
+	if out, _, err := runCommandWithOutput(buildCmd); err != nil {
+		t.Fatalf("failed to build with a Dockerfile from STDIN: %s, %v", out, err)
+	}
+	logDone("build - -f with a Dockerfile out of the context and from STDIN")
+}
+
+func TestBuildStopSignal(t *testing.T) {
+	name := "testbuildstopsignal"
+	defer deleteImages(name)
+	defer deleteAllContainers()
+	_, err := buildImage(name,
+		`FROM busybox
+		STOPSIGNAL SIGUSR1`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := inspectField(name, "Config.StopSignal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "SIGUSR1" {
+		t.Fatalf("StopSignal %s, expected SIGUSR1", res)
+	}
+
+	// docker stop sends the stop signal of the image
+	runCmd := exec.Command(dockerBinary, "run", "-d", name, "sh", "-c", `trap "echo stopped; exit 0" USR1; while true; do sleep 1; done`)
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to run the container: %s, %v", out, err)
+	}
+	id := strings.TrimSpace(out)
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", id)); err != nil {
+		t.Fatalf("failed to stop the container: %s, %v", out, err)
+	}
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "logs", id))
+	if err != nil || !strings.Contains(out, "stopped") {
+		t.Fatalf("the container should have trapped SIGUSR1: %s, %v", out, err)
+	}
+
+	if _, err := buildImage(name, "FROM busybox\nSTOPSIGNAL SIGFOO", true); err == nil || !strings.Contains(err.Error(), "Invalid signal: SIGFOO") {
+		t.Fatalf("build with an invalid stop signal should fail: %v", err)
+	}
+	logDone("build - STOPSIGNAL")
+}
+
+func TestBuildShell(t *testing.T) {
+	name := "testbuildshell"
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox
+		SHELL ["/bin/sh", "-e", "-c"]
+		RUN false; echo not reached > /shell
+		RUN [ ! -e /shell ]
+		CMD echo hello`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without -e the first RUN would have created /shell
+	res, err := inspectFieldJSON(name, "Config.Cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `["/bin/sh","-e","-c","echo hello"]`; res != expected {
+		t.Fatalf("Cmd %s, expected %s", res, expected)
+	}
+
+	if _, err := buildImage(name, "FROM busybox\nSHELL /bin/bash -c", true); err == nil || !strings.Contains(err.Error(), "JSON form") {
+		t.Fatalf("build with a SHELL not in JSON form should fail: %v", err)
+	}
+	logDone("build - SHELL")
+}
+
+func TestBuildHealthcheck(t *testing.T) {
+	name := "testbuildhealthcheck"
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox
+		HEALTHCHECK --interval=5s --retries=2 CMD cat /etc/passwd`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := inspectFieldJSON(name, "Config.Healthcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"Test":["CMD-SHELL","cat /etc/passwd"],"Interval":5000000000,"Timeout":0,"Retries":2}`; res != expected {
+		t.Fatalf("Healthcheck %s, expected %s", res, expected)
+	}
+
+	_, err = buildImage(name,
+		`FROM busybox
+		HEALTHCHECK HTTP 8080/ping
+		HEALTHCHECK NONE`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = inspectFieldJSON(name, "Config.Healthcheck.Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `["NONE"]`; res != expected {
+		t.Fatalf("Healthcheck test %s, expected %s", res, expected)
+	}
+
+	if _, err := buildImage(name, "FROM busybox\nHEALTHCHECK PING 80", true); err == nil || !strings.Contains(err.Error(), "Unknown HEALTHCHECK type") {
+		t.Fatalf("build with an unknown health check should fail: %v", err)
+	}
+	logDone("build - HEALTHCHECK")
+}
+
 func TestBuildDockerignoringWholeDir(t *testing.T) {
 	name := "testbuilddockerignorewholedir"
 	defer deleteImages(name)
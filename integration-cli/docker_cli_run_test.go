@@ -2643,6 +2643,148 @@ func TestRunModeIpcContainer(t *testing.T) {
 	logDone("run - hostname and several network modes")
 }
 
+func TestRunSeccompProfile(t *testing.T) {
+	defer deleteAllContainers()
+
+	tmpDir, err := ioutil.TempDir("", "docker-seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	profile := filepath.Join(tmpDir, "profile.json")
+	if err := ioutil.WriteFile(profile, []byte(`{
+	"defaultAction": "SCMP_ACT_ALLOW",
+	"syscalls": [
+		{"name": "chmod", "action": "SCMP_ACT_ERRNO"},
+		{"name": "fchmodat", "action": "SCMP_ACT_ERRNO"}
+	]
+}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(dockerBinary, "run", "--security-opt", "seccomp="+profile, "busybox", "sh", "-c", "touch /tmp/file && chmod 400 /tmp/file")
+	out, _, err := runCommandWithOutput(cmd)
+	if err == nil || !strings.Contains(out, "Operation not permitted") {
+		t.Fatalf("expected chmod to be denied by the seccomp profile, got %v: %s", err, out)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "--security-opt", "seccomp=unconfined", "busybox", "sh", "-c", "touch /tmp/file && chmod 400 /tmp/file")
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		t.Fatal(err, out)
+	}
+
+	logDone("run - seccomp profile")
+}
+
+func TestRunModePidHost(t *testing.T) {
+	defer deleteAllContainers()
+
+	hostPid, err := os.Readlink("/proc/1/ns/pid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(dockerBinary, "run", "--pid=host", "busybox", "readlink", "/proc/self/ns/pid")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	out = strings.Trim(out, "\n")
+	if hostPid != out {
+		t.Fatalf("PID different with --pid=host %s != %s\n", hostPid, out)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "busybox", "readlink", "/proc/self/ns/pid")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	out = strings.Trim(out, "\n")
+	if hostPid == out {
+		t.Fatalf("PID should be different without --pid=host %s == %s\n", hostPid, out)
+	}
+
+	logDone("run - pid host mode")
+}
+
+func TestRunModePidContainer(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "busybox", "top")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(out)
+	if err := waitRun(id); err != nil {
+		t.Fatal(err)
+	}
+	pid1, err := inspectField(id, "State.Pid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parentContainerPid, err := os.Readlink(fmt.Sprintf("/proc/%s/ns/pid", pid1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(dockerBinary, "run", fmt.Sprintf("--pid=container:%s", id), "busybox", "readlink", "/proc/self/ns/pid")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	out = strings.Trim(out, "\n")
+	if parentContainerPid != out {
+		t.Fatalf("PID different with --pid=container:%s %s != %s\n", id, parentContainerPid, out)
+	}
+
+	// the processes of the other container are visible
+	cmd = exec.Command(dockerBinary, "run", fmt.Sprintf("--pid=container:%s", id), "busybox", "ps")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.Contains(out, "top") {
+		t.Fatalf("expected to see the top process of %s, got %s", id, out)
+	}
+
+	logDone("run - pid container mode")
+}
+
+func TestRunModeUTSHost(t *testing.T) {
+	defer deleteAllContainers()
+
+	hostUTS, err := os.Readlink("/proc/1/ns/uts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(dockerBinary, "run", "--uts=host", "busybox", "readlink", "/proc/self/ns/uts")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	out = strings.Trim(out, "\n")
+	if hostUTS != out {
+		t.Fatalf("UTS different with --uts=host %s != %s\n", hostUTS, out)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(dockerBinary, "run", "--uts=host", "busybox", "hostname")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if out = strings.TrimSpace(out); out != hostname {
+		t.Fatalf("expected the hostname of the host %s, got %s", hostname, out)
+	}
+
+	logDone("run - uts host mode")
+}
+
 func TestContainerNetworkMode(t *testing.T) {
 	cmd := exec.Command(dockerBinary, "run", "-d", "busybox", "top")
 	out, _, err := runCommandWithOutput(cmd)
@@ -2771,3 +2913,134 @@ func TestRunTtyWithPipe(t *testing.T) {
 
 	logDone("run - forbid piped stdin with tty")
 }
+
+func TestRunContainerWithReadonlyRootfs(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "--read-only", "--rm", "busybox", "touch", "/file")
+	out, _, err := runCommandWithOutput(cmd)
+	if err == nil {
+		t.Fatal("expected container to error on run with read only error")
+	}
+	expected := "Read-only file system"
+	if !strings.Contains(out, expected) {
+		t.Fatalf("expected output from failure to contain %s but contains %s", expected, out)
+	}
+
+	for _, f := range []string{"/etc/hosts", "/etc/resolv.conf", "/etc/hostname", "/data/file"} {
+		cmd = exec.Command(dockerBinary, "run", "--read-only", "--rm", "-v", "/data", "busybox", "touch", f)
+		if out, _, err := runCommandWithOutput(cmd); err != nil {
+			t.Fatalf("%s should be writable: %s, %v", f, out, err)
+		}
+	}
+
+	logDone("run - read only rootfs")
+}
+
+func TestRunWithUlimits(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "--ulimit", "nofile=42", "--rm", "busybox", "sh", "-c", "ulimit -n")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if ul := strings.TrimSpace(out); ul != "42" {
+		t.Fatalf("expected `ulimit -n` to be 42, got %s", ul)
+	}
+
+	logDone("run - ulimits are set")
+}
+
+func TestRunWithCpuQuotaAndBlkioWeight(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "--name", "limited", "--cpu-period", "50000", "--cpu-quota", "25000", "--blkio-weight", "300", "busybox", "true")
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		t.Fatal(err, out)
+	}
+
+	for field, expected := range map[string]string{
+		"HostConfig.CpuPeriod":   "50000",
+		"HostConfig.CpuQuota":    "25000",
+		"HostConfig.BlkioWeight": "300",
+	} {
+		out, err := inspectField("limited", field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != expected {
+			t.Fatalf("expected %s to be %s, got %s", field, expected, out)
+		}
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "-m", "64m", "--memory-swap", "32m", "busybox", "true")
+	out, _, err := runCommandWithOutput(cmd)
+	if err == nil {
+		t.Fatal("expected run to fail with a memory swap limit lower than the memory limit")
+	}
+	if !strings.Contains(out, "memory swap limit must be larger") {
+		t.Fatalf("unexpected error output: %s", out)
+	}
+
+	logDone("run - cpu quota and blkio weight")
+}
+
+func TestRunTmpfsMounts(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "--tmpfs", "/run:size=64m,mode=1777", "busybox", "grep", " /run ", "/proc/mounts")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	for _, option := range []string{"tmpfs", "nosuid", "nodev", "noexec", "size=65536k", "mode=1777"} {
+		if !strings.Contains(out, option) {
+			t.Fatalf("Expected the tmpfs mount to have %q, got %q", option, out)
+		}
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "--name", "tmpfs", "--tmpfs", "/scratch", "busybox", "touch", "/scratch/file")
+	if out, _, err := runCommandWithOutput(cmd); err != nil {
+		t.Fatal(err, out)
+	}
+	cmd = exec.Command(dockerBinary, "diff", "tmpfs")
+	out, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.Contains(out, "/scratch/file") {
+		t.Fatalf("Expected the files of the tmpfs not to be in the container diff, got %q", out)
+	}
+
+	logDone("run - tmpfs mounts")
+}
+
+func TestRunTmpfsMountsInvalid(t *testing.T) {
+	cmd := exec.Command(dockerBinary, "run", "--tmpfs", "/run:bind", "busybox", "true")
+	if out, _, err := runCommandWithOutput(cmd); err == nil {
+		t.Fatalf("Expected an error for a bind tmpfs option, got %q", out)
+	}
+
+	logDone("run - invalid tmpfs mounts")
+}
+
+func TestRunSysctls(t *testing.T) {
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "--sysctl", "net.core.somaxconn=1042", "busybox", "cat", "/proc/sys/net/core/somaxconn")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "1042" {
+		t.Fatalf("Expected net.core.somaxconn to be 1042, got %q", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "--net=host", "--sysctl", "net.core.somaxconn=1042", "busybox", "true")
+	if out, _, err := runCommandWithOutput(cmd); err == nil {
+		t.Fatalf("Expected an error setting a net sysctl with --net=host, got %q", out)
+	}
+
+	logDone("run - sysctls")
+}
@@ -11,6 +11,22 @@ import (
 Read in a line delimited file with environment variables enumerated
 */
 func ParseEnvFile(filename string) ([]string, error) {
+	// if only a pass-through variable is given, its value is taken from the
+	// environment
+	return parseKeyValueFile(filename, os.Getenv)
+}
+
+/*
+Read in a line delimited file with labels enumerated
+*/
+func ParseLabelFile(filename string) ([]string, error) {
+	// a label given without a value is set to the empty string
+	return parseKeyValueFile(filename, func(string) string { return "" })
+}
+
+// parseKeyValueFile reads key=value lines from filename, skipping empty lines
+// and comments. The value of a key given alone is looked up with emptyFn.
+func parseKeyValueFile(filename string, emptyFn func(string) string) ([]string, error) {
 	fh, err := os.Open(filename)
 	if err != nil {
 		return []string{}, err
@@ -35,8 +51,8 @@ func ParseEnvFile(filename string) ([]string, error) {
 				// pass the value through, no trimming
 				lines = append(lines, fmt.Sprintf("%s=%s", variable, data[1]))
 			} else {
-				// if only a pass-through variable is given, clean it up.
-				lines = append(lines, fmt.Sprintf("%s=%s", strings.TrimSpace(line), os.Getenv(line)))
+				// if only a key is given, clean it up.
+				lines = append(lines, fmt.Sprintf("%s=%s", strings.TrimSpace(line), emptyFn(line)))
 			}
 		}
 	}
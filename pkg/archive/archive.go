@@ -20,6 +20,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/idtools"
 	"github.com/docker/docker/pkg/pools"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/system"
@@ -35,6 +36,10 @@ type (
 		Compression Compression
 		NoLchown    bool
 		Name        string
+		// UIDMaps and GIDMaps map the owners of the files in the archive,
+		// which are container ids, to the ids on the host
+		UIDMaps []idtools.IDMap
+		GIDMaps []idtools.IDMap
 	}
 
 	// Archiver allows the reuse of most utility functions of this package
@@ -171,6 +176,10 @@ type tarAppender struct {
 
 	// for hardlink mapping
 	SeenFiles map[uint64]string
+
+	// for mapping the owners of the files to container ids
+	UIDMaps []idtools.IDMap
+	GIDMaps []idtools.IDMap
 }
 
 func (ta *tarAppender) addTarFile(path, name string) error {
@@ -197,6 +206,13 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 
 	hdr.Name = name
 
+	if hdr.Uid, err = idtools.ToContainer(hdr.Uid, ta.UIDMaps); err != nil {
+		return err
+	}
+	if hdr.Gid, err = idtools.ToContainer(hdr.Gid, ta.GIDMaps); err != nil {
+		return err
+	}
+
 	nlink, inode, err := setHeaderForSpecialDevice(hdr, ta, name, fi.Sys())
 	if err != nil {
 		return err
@@ -248,6 +264,20 @@ func (ta *tarAppender) addTarFile(path, name string) error {
 	return nil
 }
 
+// remapIDs changes the owner of hdr from container to host ids
+func remapIDs(hdr *tar.Header, uidMaps, gidMaps []idtools.IDMap) error {
+	uid, err := idtools.ToHost(hdr.Uid, uidMaps)
+	if err != nil {
+		return err
+	}
+	gid, err := idtools.ToHost(hdr.Gid, gidMaps)
+	if err != nil {
+		return err
+	}
+	hdr.Uid, hdr.Gid = uid, gid
+	return nil
+}
+
 func createTarFile(path, extractDir string, hdr *tar.Header, reader io.Reader, Lchown bool) error {
 	// hdr.Mode is in linux format, which we can use for sycalls,
 	// but for os.Foo() calls we need the mode converted to os.FileMode,
@@ -392,6 +422,8 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 			TarWriter: tar.NewWriter(compressWriter),
 			Buffer:    pools.BufioWriter32KPool.Get(nil),
 			SeenFiles: make(map[uint64]string),
+			UIDMaps:   options.UIDMaps,
+			GIDMaps:   options.GIDMaps,
 		}
 		// this buffer is needed for the duration of this piped stream
 		defer pools.BufioWriter32KPool.Put(ta.Buffer)
@@ -405,6 +437,9 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 			options.Includes = []string{"."}
 		}
 
+		// the files of an excluded directory may be included again
+		exceptions := fileutils.HasExceptions(options.Excludes)
+
 		var renamedRelFilePath string // For when tar.Options.Name is set
 		for _, include := range options.Includes {
 			filepath.Walk(filepath.Join(srcPath, include), func(filePath string, f os.FileInfo, err error) error {
@@ -427,7 +462,7 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 				}
 
 				if skip {
-					if f.IsDir() {
+					if f.IsDir() && !exceptions {
 						return filepath.SkipDir
 					}
 					return nil
@@ -493,6 +528,10 @@ loop:
 			}
 		}
 
+		if err := remapIDs(hdr, options.UIDMaps, options.GIDMaps); err != nil {
+			return err
+		}
+
 		if !strings.HasSuffix(hdr.Name, "/") {
 			// Not the root directory, ensure that the parent directory exists
 			parent := filepath.Dir(hdr.Name)
@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+
+	"github.com/docker/docker/pkg/idtools"
 )
 
 func TestCmdStreamLargeStderr(t *testing.T) {
@@ -211,6 +213,60 @@ func TestTarWithOptions(t *testing.T) {
 	}
 }
 
+func TestTarUntarIDMaps(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing the owner of files requires root")
+	}
+	origin, err := ioutil.TempDir("", "docker-test-untar-origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(origin)
+	if err := ioutil.WriteFile(path.Join(origin, "1"), []byte("hello world"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Lchown(path.Join(origin, "1"), 100001, 100002); err != nil {
+		t.Fatal(err)
+	}
+
+	idMap := []idtools.IDMap{{ContainerID: 0, HostID: 100000, Size: 65536}}
+	options := &TarOptions{UIDMaps: idMap, GIDMaps: idMap}
+	archive, err := TarWithOptions(origin, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadAll(archive)
+	archive.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hdr, err := tar.NewReader(bytes.NewReader(buf)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Uid != 1 || hdr.Gid != 2 {
+		t.Fatalf("expected the archive owner to be 1:2, got %d:%d", hdr.Uid, hdr.Gid)
+	}
+
+	dest, err := ioutil.TempDir("", "docker-test-untar-dest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	if err := Untar(bytes.NewReader(buf), dest, &TarOptions{UIDMaps: idMap, GIDMaps: idMap}); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Lstat(path.Join(dest, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := fi.Sys().(*syscall.Stat_t)
+	if st.Uid != 100001 || st.Gid != 100002 {
+		t.Fatalf("expected the owner to be 100001:100002, got %d:%d", st.Uid, st.Gid)
+	}
+}
+
 // Some tar archives such as http://haproxy.1wt.eu/download/1.5/src/devel/haproxy-1.5-dev21.tar.gz
 // use PAX Global Extended Headers.
 // Failing prevents the archives from being uncompressed during ADD
@@ -15,7 +15,13 @@ import (
 	"github.com/docker/docker/pkg/system"
 )
 
-func UnpackLayer(dest string, layer ArchiveReader) error {
+// UnpackLayer applies the uncompressed diff in `layer` to the directory
+// `dest`, mapping the owners of its files with `options`.
+func UnpackLayer(dest string, layer ArchiveReader, options *TarOptions) error {
+	if options == nil {
+		options = &TarOptions{}
+	}
+
 	tr := tar.NewReader(layer)
 	trBuf := pools.BufioReader32KPool.Get(tr)
 	defer pools.BufioReader32KPool.Put(trBuf)
@@ -39,6 +45,10 @@ func UnpackLayer(dest string, layer ArchiveReader) error {
 		// Normalize name, for safety and for a simple is-root check
 		hdr.Name = filepath.Clean(hdr.Name)
 
+		if err := remapIDs(hdr, options.UIDMaps, options.GIDMaps); err != nil {
+			return err
+		}
+
 		if !strings.HasSuffix(hdr.Name, "/") {
 			// Not the root directory, ensure that the parent directory exists.
 			// This happened in some tests where an image had a tarfile without any
@@ -148,6 +158,12 @@ func UnpackLayer(dest string, layer ArchiveReader) error {
 // ApplyLayer parses a diff in the standard layer format from `layer`, and
 // applies it to the directory `dest`.
 func ApplyLayer(dest string, layer ArchiveReader) error {
+	return ApplyLayerWithOptions(dest, layer, &TarOptions{})
+}
+
+// ApplyLayerWithOptions is ApplyLayer mapping the owners of the files in
+// `layer` to host ids with `options`.
+func ApplyLayerWithOptions(dest string, layer ArchiveReader, options *TarOptions) error {
 	dest = filepath.Clean(dest)
 
 	// We need to be able to set any perms
@@ -161,5 +177,5 @@ func ApplyLayer(dest string, layer ArchiveReader) error {
 	if err != nil {
 		return err
 	}
-	return UnpackLayer(dest, layer)
+	return UnpackLayer(dest, layer, options)
 }
@@ -1,6 +1,8 @@
 package chrootarchive
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -8,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 
 	"github.com/docker/docker/pkg/archive"
@@ -21,6 +24,10 @@ func applyLayer() {
 	if err := chroot(flag.Arg(0)); err != nil {
 		fatal(err)
 	}
+	var options *archive.TarOptions
+	if err := json.NewDecoder(strings.NewReader(flag.Arg(1))).Decode(&options); err != nil {
+		fatal(err)
+	}
 	// We need to be able to set any perms
 	oldmask := syscall.Umask(0)
 	defer syscall.Umask(oldmask)
@@ -29,7 +36,7 @@ func applyLayer() {
 		fatal(err)
 	}
 	os.Setenv("TMPDIR", tmpDir)
-	err = archive.UnpackLayer("/", os.Stdin)
+	err = archive.UnpackLayer("/", os.Stdin, options)
 	os.RemoveAll(tmpDir)
 	if err != nil {
 		fatal(err)
@@ -40,6 +47,19 @@ func applyLayer() {
 }
 
 func ApplyLayer(dest string, layer archive.ArchiveReader) error {
+	return ApplyLayerWithOptions(dest, layer, &archive.TarOptions{})
+}
+
+// ApplyLayerWithOptions is ApplyLayer mapping the owners of the files in
+// the layer to host ids with options.
+func ApplyLayerWithOptions(dest string, layer archive.ArchiveReader, options *archive.TarOptions) error {
+	if options == nil {
+		options = &archive.TarOptions{}
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(options); err != nil {
+		return fmt.Errorf("ApplyLayer json encode: %v", err)
+	}
 	dest = filepath.Clean(dest)
 	decompressed, err := archive.DecompressStream(layer)
 	if err != nil {
@@ -50,7 +70,7 @@ func ApplyLayer(dest string, layer archive.ArchiveReader) error {
 			c.Close()
 		}
 	}()
-	cmd := reexec.Command("docker-applyLayer", dest)
+	cmd := reexec.Command("docker-applyLayer", dest, buf.String())
 	cmd.Stdin = decompressed
 	out, err := cmd.CombinedOutput()
 	if err != nil {
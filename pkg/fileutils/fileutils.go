@@ -1,26 +1,198 @@
 package fileutils
 
 import (
-	log "github.com/Sirupsen/logrus"
+	"bufio"
+	"fmt"
+	"io"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
 )
 
-// Matches returns true if relFilePath matches any of the patterns
+// compiled patterns, as Matches is called for every file of a tree
+var compiledPatterns = struct {
+	sync.Mutex
+	regexps map[string]*regexp.Regexp
+}{regexps: map[string]*regexp.Regexp{}}
+
+// Matches returns true if relFilePath matches any of the patterns. As in a
+// .dockerignore file, the last pattern matching the path wins and a pattern
+// starting with "!" is an exception which includes the path again. A
+// pattern matching a directory matches the paths under it, and "**" matches
+// any number of directories.
 func Matches(relFilePath string, patterns []string) (bool, error) {
-	for _, exclude := range patterns {
-		matched, err := filepath.Match(exclude, relFilePath)
+	relFilePath = filepath.Clean(relFilePath)
+	matched := false
+	for _, pattern := range patterns {
+		exception := strings.HasPrefix(pattern, "!")
+		if exception {
+			pattern = pattern[1:]
+		}
+		// only the patterns which would change the result matter
+		if matched != exception {
+			continue
+		}
+		match, err := matchPattern(pattern, relFilePath)
 		if err != nil {
-			log.Errorf("Error matching: %s (pattern: %s)", relFilePath, exclude)
+			log.Errorf("Error matching: %s (pattern: %s)", relFilePath, pattern)
 			return false, err
 		}
-		if matched {
-			if filepath.Clean(relFilePath) == "." {
-				log.Errorf("Can't exclude whole path, excluding pattern: %s", exclude)
+		if match {
+			if relFilePath == "." {
+				log.Errorf("Can't exclude whole path, excluding pattern: %s", pattern)
 				continue
 			}
-			log.Debugf("Skipping excluded path: %s", relFilePath)
+			matched = !exception
+		}
+	}
+	if matched {
+		log.Debugf("Skipping excluded path: %s", relFilePath)
+	}
+	return matched, nil
+}
+
+// HasExceptions returns true if some of the patterns are exceptions, the
+// directories matched by the other patterns can't be skipped as a whole then.
+func HasExceptions(patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadPatterns reads the patterns of a .dockerignore file, one per line.
+// Empty lines and lines starting with "#" are skipped.
+func ReadPatterns(r io.Reader) ([]string, error) {
+	var excludes []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		exception := strings.HasPrefix(pattern, "!")
+		if exception {
+			pattern = strings.TrimSpace(pattern[1:])
+		}
+		pattern = filepath.Clean(pattern)
+		if _, err := compilePattern(pattern); err != nil {
+			return nil, fmt.Errorf("Bad .dockerignore pattern: '%s', error: %s", pattern, err)
+		}
+		if exception {
+			pattern = "!" + pattern
+		}
+		excludes = append(excludes, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return excludes, nil
+}
+
+// matchPattern returns true if the pattern matches the path or one of its
+// parent directories.
+func matchPattern(pattern, relFilePath string) (bool, error) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	if relFilePath == "." {
+		return re.MatchString(relFilePath), nil
+	}
+	for p := relFilePath; p != "." && p != "/"; p = filepath.Dir(p) {
+		if re.MatchString(p) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
+
+// compilePattern translates the filepath.Match syntax, extended with "**",
+// to a regexp.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	compiledPatterns.Lock()
+	defer compiledPatterns.Unlock()
+	if re, ok := compiledPatterns.regexps[pattern]; ok {
+		return re, nil
+	}
+
+	expr := "^"
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					expr += "(.*/)?"
+				} else {
+					expr += ".*"
+				}
+			} else {
+				expr += "[^/]*"
+			}
+		case '?':
+			expr += "[^/]"
+		case '[':
+			class, n, err := compileClass(pattern[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			expr += class
+			i += n
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, filepath.ErrBadPattern
+			}
+			i++
+			expr += regexp.QuoteMeta(string(pattern[i]))
+		default:
+			expr += regexp.QuoteMeta(string(ch))
+		}
+	}
+	expr += "$"
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, filepath.ErrBadPattern
+	}
+	compiledPatterns.regexps[pattern] = re
+	return re, nil
+}
+
+// compileClass translates the character class at the start of s, after its
+// "[", and returns it with the number of bytes of s it used.
+func compileClass(s string) (string, int, error) {
+	class := "["
+	i := 0
+	if i < len(s) && s[i] == '^' {
+		class += "^/"
+		i++
+	}
+	start := i
+	for ; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == ']' && i > start:
+			return class + "]", i + 1, nil
+		case ch == '\\':
+			if i+1 == len(s) {
+				return "", 0, filepath.ErrBadPattern
+			}
+			i++
+			class += regexp.QuoteMeta(string(s[i]))
+		case ch == '-':
+			class += "-"
+		case ch == '[' || ch == ']' || ch == '^':
+			class += `\` + string(ch)
+		default:
+			class += string(ch)
+		}
+	}
+	return "", 0, filepath.ErrBadPattern
+}
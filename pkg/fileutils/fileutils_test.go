@@ -0,0 +1,71 @@
+package fileutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		path     string
+		patterns []string
+		match    bool
+	}{
+		{"foo", []string{"foo"}, true},
+		{"foo/bar", []string{"foo"}, true},
+		{"bar/foo", []string{"foo"}, false},
+		{"foo.md", []string{"*.md"}, true},
+		{"docs/foo.md", []string{"*.md"}, false},
+		{"docs/foo.md", []string{"**/*.md"}, true},
+		{"foo.md", []string{"**/*.md"}, true},
+		{"a/b/c/foo.md", []string{"a/**/foo.md"}, true},
+		{"a/foo.md", []string{"a/**/foo.md"}, true},
+		{"a/b/c", []string{"a/**"}, true},
+		{"foo1", []string{"foo?"}, true},
+		{"foo/1", []string{"foo?"}, false},
+		{"foo1", []string{"foo[0-9]"}, true},
+		{"fooa", []string{"foo[^0-9]"}, true},
+		{"foo1", []string{"foo[^0-9]"}, false},
+		{"foo*", []string{`foo\*`}, true},
+		{"foobar", []string{`foo\*`}, false},
+		{"docs/README.md", []string{"docs", "!docs/README.md"}, false},
+		{"docs/index.md", []string{"docs", "!docs/README.md"}, true},
+		{"docs/README.md", []string{"docs", "!docs/README.md", "**/*.md"}, true},
+		{"README.md", []string{"*.md", "!README*.md", "README-secret.md"}, false},
+		{"README-secret.md", []string{"*.md", "!README*.md", "README-secret.md"}, true},
+		{".", []string{"*"}, false},
+		{"foo", []string{".*"}, false},
+		{".git/HEAD", []string{".*"}, true},
+	}
+	for _, test := range tests {
+		match, err := Matches(test.path, test.patterns)
+		if err != nil {
+			t.Fatalf("%s with %v: %s", test.path, test.patterns, err)
+		}
+		if match != test.match {
+			t.Fatalf("%s with %v: expected %v, got %v", test.path, test.patterns, test.match, match)
+		}
+	}
+
+	if _, err := Matches("foo", []string{"[foo"}); err == nil {
+		t.Fatal("Expected an error for a bad pattern")
+	}
+}
+
+func TestReadPatterns(t *testing.T) {
+	patterns, err := ReadPatterns(strings.NewReader("# comment\n\n./foo\n dir1//bar \n! docs/README.md\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"foo", "dir1/bar", "!docs/README.md"}
+	if strings.Join(patterns, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected %v, got %v", expected, patterns)
+	}
+	if !HasExceptions(patterns) {
+		t.Fatal("Expected exceptions")
+	}
+
+	if _, err := ReadPatterns(strings.NewReader("foo[\n")); err == nil {
+		t.Fatal("Expected an error for a bad pattern")
+	}
+}
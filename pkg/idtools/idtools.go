@@ -0,0 +1,227 @@
+package idtools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/libcontainer/user"
+)
+
+// IDMap maps the Size ids of a user namespace starting at ContainerID to
+// the host ids starting at HostID
+type IDMap struct {
+	ContainerID int `json:"container_id"`
+	HostID      int `json:"host_id"`
+	Size        int `json:"size"`
+}
+
+// subIDRange is a range of subordinate ids from /etc/subuid or /etc/subgid
+type subIDRange struct {
+	Start  int
+	Length int
+}
+
+type subIDRanges []subIDRange
+
+func (r subIDRanges) Len() int           { return len(r) }
+func (r subIDRanges) Less(i, j int) bool { return r[i].Start < r[j].Start }
+func (r subIDRanges) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+const (
+	subuidFileName = "/etc/subuid"
+	subgidFileName = "/etc/subgid"
+)
+
+// CreateIDMappings returns the uid and gid maps placing the ids of a user
+// namespace, starting with root, onto the ranges subordinate to username
+// in /etc/subuid and to groupname in /etc/subgid.  The names may also be
+// a numeric uid and gid.
+func CreateIDMappings(username, groupname string) ([]IDMap, []IDMap, error) {
+	u, err := lookupUser(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := lookupGroup(groupname)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uidRanges, err := parseSubIDs(subuidFileName, u.Name, u.Uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(uidRanges) == 0 {
+		return nil, nil, fmt.Errorf("No subuid ranges found for user %q in %s", u.Name, subuidFileName)
+	}
+	gidRanges, err := parseSubIDs(subgidFileName, g.Name, g.Gid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(gidRanges) == 0 {
+		return nil, nil, fmt.Errorf("No subgid ranges found for group %q in %s", g.Name, subgidFileName)
+	}
+	return createIDMap(uidRanges), createIDMap(gidRanges), nil
+}
+
+// createIDMap maps the container ids, from 0 upwards, onto the ranges in
+// the order of their start
+func createIDMap(ranges subIDRanges) []IDMap {
+	sort.Sort(ranges)
+	var (
+		idMap       []IDMap
+		containerID int
+	)
+	for _, r := range ranges {
+		idMap = append(idMap, IDMap{
+			ContainerID: containerID,
+			HostID:      r.Start,
+			Size:        r.Length,
+		})
+		containerID += r.Length
+	}
+	return idMap
+}
+
+// parseSubIDs returns the ranges of the name:start:length lines of the file
+// at path whose name is name or the numeric id
+func parseSubIDs(path, name string, id int) (subIDRanges, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		ranges subIDRanges
+		s      = bufio.NewScanner(f)
+	)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("Invalid line in %s: %q", path, line)
+		}
+		if parts[0] != name && parts[0] != strconv.Itoa(id) {
+			continue
+		}
+		start, err := strconv.Atoi(parts[1])
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("Invalid start of range in %s: %q", path, line)
+		}
+		length, err := strconv.Atoi(parts[2])
+		if err != nil || length <= 0 {
+			return nil, fmt.Errorf("Invalid length of range in %s: %q", path, line)
+		}
+		ranges = append(ranges, subIDRange{Start: start, Length: length})
+	}
+	return ranges, s.Err()
+}
+
+func lookupUser(name string) (user.User, error) {
+	if u, err := user.LookupUser(name); err == nil {
+		return u, nil
+	}
+	uid, err := strconv.Atoi(name)
+	if err != nil {
+		return user.User{}, fmt.Errorf("Unable to find user %q", name)
+	}
+	u, err := user.LookupUid(uid)
+	if err != nil {
+		return user.User{}, fmt.Errorf("Unable to find user %q", name)
+	}
+	return u, nil
+}
+
+func lookupGroup(name string) (user.Group, error) {
+	if g, err := user.LookupGroup(name); err == nil {
+		return g, nil
+	}
+	gid, err := strconv.Atoi(name)
+	if err != nil {
+		return user.Group{}, fmt.Errorf("Unable to find group %q", name)
+	}
+	g, err := user.LookupGid(gid)
+	if err != nil {
+		return user.Group{}, fmt.Errorf("Unable to find group %q", name)
+	}
+	return g, nil
+}
+
+// ToHost returns the host id of the container id contID.  Ids are left
+// unchanged when there is no map.
+func ToHost(contID int, idMap []IDMap) (int, error) {
+	if idMap == nil {
+		return contID, nil
+	}
+	for _, m := range idMap {
+		if contID >= m.ContainerID && contID < m.ContainerID+m.Size {
+			return m.HostID + contID - m.ContainerID, nil
+		}
+	}
+	return -1, fmt.Errorf("Container ID %d cannot be mapped to a host ID", contID)
+}
+
+// ToContainer returns the container id of the host id hostID.  Ids are
+// left unchanged when there is no map.
+func ToContainer(hostID int, idMap []IDMap) (int, error) {
+	if idMap == nil {
+		return hostID, nil
+	}
+	for _, m := range idMap {
+		if hostID >= m.HostID && hostID < m.HostID+m.Size {
+			return m.ContainerID + hostID - m.HostID, nil
+		}
+	}
+	return -1, fmt.Errorf("Host ID %d cannot be mapped to a container ID", hostID)
+}
+
+// GetRootUIDGID returns the host uid and gid of root in the container
+func GetRootUIDGID(uidMap, gidMap []IDMap) (int, int, error) {
+	uid, err := ToHost(0, uidMap)
+	if err != nil {
+		return -1, -1, err
+	}
+	gid, err := ToHost(0, gidMap)
+	if err != nil {
+		return -1, -1, err
+	}
+	return uid, gid, nil
+}
+
+// MkdirAllAs creates the directory path and its missing parents like
+// os.MkdirAll, and makes ownerUID and ownerGID the owners of the
+// directories it created and of path.
+func MkdirAllAs(path string, mode os.FileMode, ownerUID, ownerGID int) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	paths := []string{path}
+	for p := filepath.Dir(path); p != filepath.Dir(p); p = filepath.Dir(p) {
+		if _, err := os.Stat(p); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		paths = append(paths, p)
+	}
+
+	if err := os.MkdirAll(path, mode); err != nil && !os.IsExist(err) {
+		return err
+	}
+	for _, p := range paths {
+		if err := os.Chown(p, ownerUID, ownerGID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
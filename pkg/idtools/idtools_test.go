@@ -0,0 +1,120 @@
+package idtools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSubIDs(t *testing.T) {
+	f, err := ioutil.TempFile("", "subuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# subordinate ids\nother:100000:65536\ndockremap:231072:65536\n1000:165536:1000\n\ndockremap:500000:10\n")
+	f.Close()
+
+	ranges, err := parseSubIDs(f.Name(), "dockremap", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := subIDRanges{{231072, 65536}, {165536, 1000}, {500000, 10}}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Fatalf("expected ranges %v, got %v", expected, ranges)
+	}
+
+	idMap := createIDMap(ranges)
+	expectedMap := []IDMap{
+		{ContainerID: 0, HostID: 165536, Size: 1000},
+		{ContainerID: 1000, HostID: 231072, Size: 65536},
+		{ContainerID: 66536, HostID: 500000, Size: 10},
+	}
+	if !reflect.DeepEqual(idMap, expectedMap) {
+		t.Fatalf("expected map %v, got %v", expectedMap, idMap)
+	}
+
+	if ranges, err := parseSubIDs(f.Name(), "nobody", 65534); err != nil || len(ranges) != 0 {
+		t.Fatalf("expected no ranges, got %v (%v)", ranges, err)
+	}
+}
+
+func TestParseSubIDsInvalid(t *testing.T) {
+	for _, content := range []string{
+		"dockremap:100000\n",
+		"dockremap:foo:65536\n",
+		"dockremap:100000:0\n",
+	} {
+		f, err := ioutil.TempFile("", "subuid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(content)
+		f.Close()
+		_, err = parseSubIDs(f.Name(), "dockremap", 1000)
+		os.Remove(f.Name())
+		if err == nil {
+			t.Fatalf("expected an error parsing %q", content)
+		}
+	}
+}
+
+func TestToHostToContainer(t *testing.T) {
+	idMap := []IDMap{
+		{ContainerID: 0, HostID: 100000, Size: 1000},
+		{ContainerID: 1000, HostID: 300000, Size: 10},
+	}
+	for _, c := range []struct{ container, host int }{
+		{0, 100000},
+		{999, 100999},
+		{1000, 300000},
+		{1009, 300009},
+	} {
+		if host, err := ToHost(c.container, idMap); err != nil || host != c.host {
+			t.Fatalf("expected container id %d to map to %d, got %d (%v)", c.container, c.host, host, err)
+		}
+		if container, err := ToContainer(c.host, idMap); err != nil || container != c.container {
+			t.Fatalf("expected host id %d to map to %d, got %d (%v)", c.host, c.container, container, err)
+		}
+	}
+	if _, err := ToHost(1010, idMap); err == nil {
+		t.Fatal("expected an error mapping an id outside of the ranges")
+	}
+	if _, err := ToContainer(0, idMap); err == nil {
+		t.Fatal("expected an error mapping host root")
+	}
+	if host, err := ToHost(42, nil); err != nil || host != 42 {
+		t.Fatalf("expected ids to be unchanged without a map, got %d (%v)", host, err)
+	}
+
+	uid, gid, err := GetRootUIDGID(idMap, []IDMap{{ContainerID: 0, HostID: 200000, Size: 1}})
+	if err != nil || uid != 100000 || gid != 200000 {
+		t.Fatalf("expected root to be 100000:200000, got %d:%d (%v)", uid, gid, err)
+	}
+}
+
+func TestMkdirAllAs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "idtools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	uid, gid := os.Getuid(), os.Getgid()
+	dir := filepath.Join(tmp, "a", "b")
+	if err := MkdirAllAs(dir, 0700, uid, gid); err != nil {
+		t.Fatal(err)
+	}
+	if err := MkdirAllAs(dir, 0700, uid, gid); err != nil {
+		t.Fatalf("expected an existing directory to be accepted: %s", err)
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.IsDir() || fi.Mode().Perm() != 0700 {
+		t.Fatalf("expected a 0700 directory, got %s", fi.Mode())
+	}
+}
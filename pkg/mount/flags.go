@@ -1,6 +1,7 @@
 package mount
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -67,3 +68,31 @@ func parseOptions(options string) (int, string) {
 	}
 	return flag, strings.Join(data, ",")
 }
+
+// ParseTmpfsOptions parses the fstab type options of a tmpfs mount into
+// mount() flags and the tmpfs specific data.  Options changing the kind of
+// mount, such as bind or the propagation flags, are rejected.
+func ParseTmpfsOptions(options string) (int, string, error) {
+	flag, data := parseOptions(options)
+	if flag&(BIND|REMOUNT|UNBINDABLE|PRIVATE|SLAVE|SHARED) != 0 {
+		return 0, "", fmt.Errorf("Invalid tmpfs options %q: only the flags and the options of a new mount are allowed", options)
+	}
+	validData := map[string]bool{
+		"size":      true,
+		"nr_blocks": true,
+		"nr_inodes": true,
+		"mode":      true,
+		"uid":       true,
+		"gid":       true,
+		"mpol":      true,
+	}
+	for _, o := range strings.Split(data, ",") {
+		if o == "" {
+			continue
+		}
+		if kv := strings.SplitN(o, "=", 2); len(kv) != 2 || !validData[kv[0]] {
+			return 0, "", fmt.Errorf("Invalid tmpfs option %q", o)
+		}
+	}
+	return flag, data, nil
+}
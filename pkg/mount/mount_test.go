@@ -22,6 +22,25 @@ func TestMountOptionsParsing(t *testing.T) {
 	}
 }
 
+func TestParseTmpfsOptions(t *testing.T) {
+	flag, data, err := ParseTmpfsOptions("noexec,nosuid,nodev,exec,size=64m,mode=1777")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != "size=64m,mode=1777" {
+		t.Fatalf("Expected size=64m,mode=1777 got %s", data)
+	}
+	if expectedFlag := NOSUID | NODEV; flag != expectedFlag {
+		t.Fatalf("Expected %d got %d", expectedFlag, flag)
+	}
+
+	for _, options := range []string{"bind", "rshared", "remount", "foo=bar", "size=64m,mode"} {
+		if _, _, err := ParseTmpfsOptions(options); err == nil {
+			t.Fatalf("Expected an error parsing %q", options)
+		}
+	}
+}
+
 func TestMounted(t *testing.T) {
 	tmp := path.Join(os.TempDir(), "mount-tests")
 	if err := os.MkdirAll(tmp, 0777); err != nil {
@@ -83,3 +83,24 @@ func (filters Args) Match(field, source string) bool {
 	}
 	return false
 }
+
+// MatchKVList returns true if sources holds every key=value pair of the
+// filters set for field. A filter without a value only requires the key to
+// be present.
+func (filters Args) MatchKVList(field string, sources map[string]string) bool {
+	fieldValues := filters[field]
+
+	//do not filter if there is no filter set or cannot determine filter
+	if len(fieldValues) == 0 {
+		return true
+	}
+
+	for _, name2match := range fieldValues {
+		testKV := strings.SplitN(name2match, "=", 2)
+		v, exists := sources[testKV[0]]
+		if !exists || (len(testKV) == 2 && v != testKV[1]) {
+			return false
+		}
+	}
+	return true
+}
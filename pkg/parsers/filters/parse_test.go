@@ -76,3 +76,40 @@ func TestEmpty(t *testing.T) {
 		t.Errorf("these should both be empty sets")
 	}
 }
+
+func TestMatchKVList(t *testing.T) {
+	sources := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+		"key3": "",
+	}
+
+	matches := map[string]Args{
+		"no filter":        {},
+		"key":              {"label": {"key1"}},
+		"key and value":    {"label": {"key1=value1"}},
+		"empty value":      {"label": {"key3="}},
+		"several filters":  {"label": {"key1=value1", "key2"}},
+		"other field only": {"name": {"key4"}},
+	}
+	for name, args := range matches {
+		if !args.MatchKVList("label", sources) {
+			t.Errorf("%s: expected a match", name)
+		}
+	}
+
+	mismatches := map[string]Args{
+		"missing key":    {"label": {"key4"}},
+		"other value":    {"label": {"key1=value2"}},
+		"one filter out": {"label": {"key1=value1", "key4"}},
+	}
+	for name, args := range mismatches {
+		if args.MatchKVList("label", sources) {
+			t.Errorf("%s: expected no match", name)
+		}
+	}
+
+	if (Args{"label": {"key1"}}).MatchKVList("label", nil) {
+		t.Errorf("expected no match without labels")
+	}
+}
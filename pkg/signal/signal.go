@@ -1,8 +1,12 @@
 package signal
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 )
 
 func CatchAll(sigc chan os.Signal) {
@@ -17,3 +21,20 @@ func StopCatch(sigc chan os.Signal) {
 	signal.Stop(sigc)
 	close(sigc)
 }
+
+// ParseSignal translates a signal number, or a name like "KILL" or
+// "SIGKILL", to a syscall.Signal.
+func ParseSignal(rawSignal string) (syscall.Signal, error) {
+	// The largest legal signal is 31, so let's parse on 5 bits
+	if s, err := strconv.ParseUint(rawSignal, 10, 5); err == nil {
+		if s == 0 {
+			return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+		}
+		return syscall.Signal(s), nil
+	}
+	sig, ok := SignalMap[strings.TrimPrefix(strings.ToUpper(rawSignal), "SIG")]
+	if !ok {
+		return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+	}
+	return sig, nil
+}
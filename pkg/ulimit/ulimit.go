@@ -0,0 +1,137 @@
+// Package ulimit parses the resource limits of processes given as
+// name=soft[:hard], like ulimit(1) names them.
+package ulimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Unlimited is the value of a limit set to "unlimited".
+const Unlimited int64 = -1
+
+// Ulimit is a resource limit as given by the user.
+type Ulimit struct {
+	Name string
+	Hard int64
+	Soft int64
+}
+
+// Rlimit is a resource limit as passed to setrlimit(2).
+type Rlimit struct {
+	Type int    `json:"type,omitempty"`
+	Hard uint64 `json:"hard,omitempty"`
+	Soft uint64 `json:"soft,omitempty"`
+}
+
+const (
+	// Linux resource numbers. The syscall package does not define all of
+	// them, nor does it on every platform the client is built for.
+	rlimitAs         = 9
+	rlimitCore       = 4
+	rlimitCpu        = 0
+	rlimitData       = 2
+	rlimitFsize      = 1
+	rlimitLocks      = 10
+	rlimitMemlock    = 8
+	rlimitMsgqueue   = 12
+	rlimitNice       = 13
+	rlimitNofile     = 7
+	rlimitNproc      = 6
+	rlimitRss        = 5
+	rlimitRtprio     = 14
+	rlimitRttime     = 15
+	rlimitSigpending = 11
+	rlimitStack      = 3
+
+	rlimInfinity = ^uint64(0)
+)
+
+var ulimitNameMapping = map[string]int{
+	"as":         rlimitAs,
+	"core":       rlimitCore,
+	"cpu":        rlimitCpu,
+	"data":       rlimitData,
+	"fsize":      rlimitFsize,
+	"locks":      rlimitLocks,
+	"memlock":    rlimitMemlock,
+	"msgqueue":   rlimitMsgqueue,
+	"nice":       rlimitNice,
+	"nofile":     rlimitNofile,
+	"nproc":      rlimitNproc,
+	"rss":        rlimitRss,
+	"rtprio":     rlimitRtprio,
+	"rttime":     rlimitRttime,
+	"sigpending": rlimitSigpending,
+	"stack":      rlimitStack,
+}
+
+// Parse parses a limit given as name=soft:hard, or name=limit to set both
+// the soft and the hard limit. A value can be "unlimited".
+func Parse(val string) (*Ulimit, error) {
+	parts := strings.SplitN(val, "=", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid ulimit argument: %s", val)
+	}
+
+	if _, exists := ulimitNameMapping[parts[0]]; !exists {
+		return nil, fmt.Errorf("invalid ulimit type: %s", parts[0])
+	}
+
+	limits := strings.SplitN(parts[1], ":", 2)
+	soft, err := parseLimit(limits[0])
+	if err != nil {
+		return nil, err
+	}
+	hard := soft
+	if len(limits) == 2 {
+		if hard, err = parseLimit(limits[1]); err != nil {
+			return nil, err
+		}
+	}
+	if hard != Unlimited && (soft == Unlimited || soft > hard) {
+		return nil, fmt.Errorf("ulimit soft limit must be less than or equal to hard limit: %s > %s", limits[0], limits[1])
+	}
+
+	return &Ulimit{Name: parts[0], Soft: soft, Hard: hard}, nil
+}
+
+func parseLimit(val string) (int64, error) {
+	if val == "unlimited" {
+		return Unlimited, nil
+	}
+	limit, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid ulimit value: %s", val)
+	}
+	return limit, nil
+}
+
+// GetRlimit returns the limit to pass to setrlimit(2).
+func (u *Ulimit) GetRlimit() (*Rlimit, error) {
+	t, exists := ulimitNameMapping[u.Name]
+	if !exists {
+		return nil, fmt.Errorf("invalid ulimit name %s", u.Name)
+	}
+
+	return &Rlimit{Type: t, Soft: rlimitValue(u.Soft), Hard: rlimitValue(u.Hard)}, nil
+}
+
+func rlimitValue(limit int64) uint64 {
+	if limit == Unlimited {
+		return rlimInfinity
+	}
+	return uint64(limit)
+}
+
+func (u *Ulimit) String() string {
+	return fmt.Sprintf("%s=%s:%s", u.Name, limitString(u.Soft), limitString(u.Hard))
+}
+
+func limitString(limit int64) string {
+	if limit == Unlimited {
+		return "unlimited"
+	}
+	return strconv.FormatInt(limit, 10)
+}
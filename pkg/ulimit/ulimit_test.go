@@ -0,0 +1,60 @@
+package ulimit
+
+import "testing"
+
+func TestParseValid(t *testing.T) {
+	for val, expected := range map[string]Ulimit{
+		"nofile=512:1024":           {Name: "nofile", Soft: 512, Hard: 1024},
+		"nproc=64":                  {Name: "nproc", Soft: 64, Hard: 64},
+		"core=0:unlimited":          {Name: "core", Soft: 0, Hard: Unlimited},
+		"memlock=unlimited":         {Name: "memlock", Soft: Unlimited, Hard: Unlimited},
+		"stack=unlimited:unlimited": {Name: "stack", Soft: Unlimited, Hard: Unlimited},
+	} {
+		u, err := Parse(val)
+		if err != nil {
+			t.Fatalf("%s: %s", val, err)
+		}
+		if *u != expected {
+			t.Fatalf("%s: expected %v, got %v", val, expected, *u)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, val := range []string{
+		"nofile",
+		"notarealtype=1024:1024",
+		"nofile=1024:",
+		"nofile=a:1024",
+		"nofile=-1",
+		"nofile=1024:512",
+		"nofile=unlimited:1024",
+	} {
+		if _, err := Parse(val); err == nil {
+			t.Fatalf("%s should not be a valid ulimit", val)
+		}
+	}
+}
+
+func TestGetRlimit(t *testing.T) {
+	u := &Ulimit{Name: "nofile", Soft: 512, Hard: Unlimited}
+	r, err := u.GetRlimit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Type != rlimitNofile || r.Soft != 512 || r.Hard != rlimInfinity {
+		t.Fatalf("unexpected rlimit %v", *r)
+	}
+
+	u.Name = "notarealtype"
+	if _, err := u.GetRlimit(); err == nil {
+		t.Fatal("expected an error for an invalid ulimit name")
+	}
+}
+
+func TestString(t *testing.T) {
+	u := &Ulimit{Name: "nofile", Soft: 1024, Hard: Unlimited}
+	if s := u.String(); s != "nofile=1024:unlimited" {
+		t.Fatalf("expected nofile=1024:unlimited, got %s", s)
+	}
+}
@@ -1,5 +1,12 @@
 package runconfig
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
 // Compare two Config struct. Do not compare the "Image" nor "Hostname" fields
 // If OpenStdin is set, then it differs
 func Compare(a, b *Config) bool {
@@ -22,7 +29,8 @@ func Compare(a, b *Config) bool {
 		len(a.PortSpecs) != len(b.PortSpecs) ||
 		len(a.ExposedPorts) != len(b.ExposedPorts) ||
 		len(a.Entrypoint) != len(b.Entrypoint) ||
-		len(a.Volumes) != len(b.Volumes) {
+		len(a.Volumes) != len(b.Volumes) ||
+		len(a.Labels) != len(b.Labels) {
 		return false
 	}
 
@@ -56,5 +64,115 @@ func Compare(a, b *Config) bool {
 			return false
 		}
 	}
+	for k, v := range a.Labels {
+		if bv, exists := b.Labels[k]; !exists || bv != v {
+			return false
+		}
+	}
+	if a.StopSignal != b.StopSignal || len(a.Shell) != len(b.Shell) {
+		return false
+	}
+	for i := 0; i < len(a.Shell); i++ {
+		if a.Shell[i] != b.Shell[i] {
+			return false
+		}
+	}
+	return compareHealthcheck(a.Healthcheck, b.Healthcheck)
+}
+
+func compareHealthcheck(a, b *HealthConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Interval != b.Interval ||
+		a.Timeout != b.Timeout ||
+		a.Retries != b.Retries ||
+		len(a.Test) != len(b.Test) {
+		return false
+	}
+	for i := 0; i < len(a.Test); i++ {
+		if a.Test[i] != b.Test[i] {
+			return false
+		}
+	}
 	return true
 }
+
+// CacheKey returns the build cache key of an image committed on top of
+// parent from a container with config. It covers the fields compared by
+// Compare, and is empty for the configs which are never cached.
+func CacheKey(parent string, config *Config) string {
+	if config == nil || config.OpenStdin {
+		return ""
+	}
+	key := struct {
+		Parent       string
+		AttachStdout bool
+		AttachStderr bool
+		User         string
+		Memory       int64
+		MemorySwap   int64
+		CpuShares    int64
+		Tty          bool
+		Cmd          []string
+		Env          []string
+		PortSpecs    []string
+		ExposedPorts []string
+		Entrypoint   []string
+		Volumes      []string
+		Labels       []string
+		Healthcheck  *HealthConfig
+		StopSignal   string
+		Shell        []string
+	}{
+		Parent:       parent,
+		AttachStdout: config.AttachStdout,
+		AttachStderr: config.AttachStderr,
+		User:         config.User,
+		Memory:       config.Memory,
+		MemorySwap:   config.MemorySwap,
+		CpuShares:    config.CpuShares,
+		Tty:          config.Tty,
+		Cmd:          nonEmpty(config.Cmd),
+		Env:          nonEmpty(config.Env),
+		PortSpecs:    nonEmpty(config.PortSpecs),
+		Entrypoint:   nonEmpty(config.Entrypoint),
+		StopSignal:   config.StopSignal,
+		Shell:        nonEmpty(config.Shell),
+	}
+	for port := range config.ExposedPorts {
+		key.ExposedPorts = append(key.ExposedPorts, string(port))
+	}
+	for volume := range config.Volumes {
+		key.Volumes = append(key.Volumes, volume)
+	}
+	for k, v := range config.Labels {
+		key.Labels = append(key.Labels, k+"="+v)
+	}
+	sort.Strings(key.ExposedPorts)
+	sort.Strings(key.Volumes)
+	sort.Strings(key.Labels)
+	if config.Healthcheck != nil {
+		key.Healthcheck = &HealthConfig{
+			Test:     nonEmpty(config.Healthcheck.Test),
+			Interval: config.Healthcheck.Interval,
+			Timeout:  config.Healthcheck.Timeout,
+			Retries:  config.Healthcheck.Retries,
+		}
+	}
+
+	buf, err := json.Marshal(key)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// nonEmpty makes the empty slices nil, as Compare doesn't tell them apart
+func nonEmpty(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
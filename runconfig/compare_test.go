@@ -0,0 +1,53 @@
+package runconfig
+
+import (
+	"testing"
+
+	"github.com/docker/docker/nat"
+)
+
+func TestCacheKey(t *testing.T) {
+	config := &Config{
+		Cmd:          []string{"/bin/sh", "-c", "#(nop) COPY file:abc in /"},
+		Env:          []string{"PATH=/bin"},
+		ExposedPorts: map[nat.Port]struct{}{"80/tcp": {}, "443/tcp": {}},
+		Labels:       map[string]string{"a": "1", "b": "2"},
+	}
+	key := CacheKey("parent", config)
+	if key == "" {
+		t.Fatal("Expected a cache key")
+	}
+
+	// the fields ignored by Compare don't change the key
+	same := *config
+	same.Hostname = "other"
+	same.Image = "other"
+	same.ExposedPorts = map[nat.Port]struct{}{"443/tcp": {}, "80/tcp": {}}
+	if CacheKey("parent", &same) != key {
+		t.Fatal("Expected the same cache key for configs which compare equal")
+	}
+	empty := &Config{Cmd: []string{}}
+	if CacheKey("parent", empty) != CacheKey("parent", &Config{}) {
+		t.Fatal("Expected the same cache key for nil and empty slices")
+	}
+
+	if CacheKey("other", config) == key {
+		t.Fatal("Expected another cache key for another parent")
+	}
+	other := *config
+	other.Cmd = []string{"/bin/sh", "-c", "#(nop) COPY file:def in /"}
+	if CacheKey("parent", &other) == key {
+		t.Fatal("Expected another cache key for another instruction")
+	}
+	other = *config
+	other.Labels = map[string]string{"a": "1", "b": "3"}
+	if CacheKey("parent", &other) == key {
+		t.Fatal("Expected another cache key for other labels")
+	}
+
+	other = *config
+	other.OpenStdin = true
+	if CacheKey("parent", &other) != "" {
+		t.Fatal("Expected no cache key with OpenStdin")
+	}
+}
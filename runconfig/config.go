@@ -1,6 +1,8 @@
 package runconfig
 
 import (
+	"time"
+
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
 )
@@ -13,7 +15,7 @@ type Config struct {
 	Domainname      string
 	User            string
 	Memory          int64  // Memory limit (in bytes)
-	MemorySwap      int64  // Total memory usage (memory + swap); set `-1' to disable swap
+	MemorySwap      int64  // Total memory usage (memory + swap); set `-1' for unlimited swap
 	CpuShares       int64  // CPU shares (relative weight vs. other containers)
 	Cpuset          string // Cpuset 0-2, 0,1
 	AttachStdin     bool
@@ -33,6 +35,27 @@ type Config struct {
 	NetworkDisabled bool
 	MacAddress      string
 	OnBuild         []string
+	Healthcheck     *HealthConfig
+	Labels          map[string]string // User-defined metadata, as key=value pairs
+	StopSignal      string            // Signal sent by docker stop, SIGTERM when empty
+	Shell           []string          // Shell of the shell form commands, /bin/sh -c when empty
+}
+
+// HealthConfig holds the configuration of a container health check.
+type HealthConfig struct {
+	// Test is the check to perform:
+	// {} inherits the check of the image,
+	// {"NONE"} disables the check,
+	// {"CMD", args...} runs args inside the container,
+	// {"CMD-SHELL", command} runs command inside the container with the
+	// container's SHELL, /bin/sh -c by default,
+	// {"TCP", port} connects to port on the container's IP address,
+	// {"HTTP", port, path} expects a 2xx or 3xx answer to a GET of path.
+	Test []string
+
+	Interval time.Duration // Time between two checks (0 means the default)
+	Timeout  time.Duration // Time after which a check is considered failed (0 means the default)
+	Retries  int           // Number of consecutive failures needed to consider the container unhealthy (0 means the default)
 }
 
 func ContainerConfigFromJob(job *engine.Job) *Config {
@@ -54,9 +77,12 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 		WorkingDir:      job.Getenv("WorkingDir"),
 		NetworkDisabled: job.GetenvBool("NetworkDisabled"),
 		MacAddress:      job.Getenv("MacAddress"),
+		StopSignal:      job.Getenv("StopSignal"),
 	}
 	job.GetenvJson("ExposedPorts", &config.ExposedPorts)
 	job.GetenvJson("Volumes", &config.Volumes)
+	job.GetenvJson("Healthcheck", &config.Healthcheck)
+	job.GetenvJson("Labels", &config.Labels)
 	if PortSpecs := job.GetenvList("PortSpecs"); PortSpecs != nil {
 		config.PortSpecs = PortSpecs
 	}
@@ -69,5 +95,8 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 	if Entrypoint := job.GetenvList("Entrypoint"); Entrypoint != nil {
 		config.Entrypoint = Entrypoint
 	}
+	if Shell := job.GetenvList("Shell"); Shell != nil {
+		config.Shell = Shell
+	}
 	return config
 }
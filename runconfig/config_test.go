@@ -190,6 +190,30 @@ func TestCompare(t *testing.T) {
 	if !Compare(&config1, &config1) {
 		t.Fatalf("Compare should return true")
 	}
+	config6 := Config{
+		PortSpecs: []string{"1111:1111", "2222:2222"},
+		Env:       []string{"VAR1=1", "VAR2=2"},
+		Volumes:   volumes1,
+		Labels:    map[string]string{"key": "value"},
+	}
+	if Compare(&config1, &config6) {
+		t.Fatalf("Compare should return false, Labels are different")
+	}
+	config7 := config6
+	config7.Labels = map[string]string{"key": "other"}
+	if Compare(&config6, &config7) {
+		t.Fatalf("Compare should return false, Label values are different")
+	}
+	config8 := config6
+	config8.StopSignal = "SIGQUIT"
+	if Compare(&config6, &config8) {
+		t.Fatalf("Compare should return false, StopSignals are different")
+	}
+	config9 := config6
+	config9.Shell = []string{"/bin/bash", "-c"}
+	if Compare(&config6, &config9) {
+		t.Fatalf("Compare should return false, Shells are different")
+	}
 }
 
 func TestMerge(t *testing.T) {
@@ -197,9 +221,12 @@ func TestMerge(t *testing.T) {
 	volumesImage["/test1"] = struct{}{}
 	volumesImage["/test2"] = struct{}{}
 	configImage := &Config{
-		PortSpecs: []string{"1111:1111", "2222:2222"},
-		Env:       []string{"VAR1=1", "VAR2=2"},
-		Volumes:   volumesImage,
+		PortSpecs:  []string{"1111:1111", "2222:2222"},
+		Env:        []string{"VAR1=1", "VAR2=2"},
+		Volumes:    volumesImage,
+		Labels:     map[string]string{"image": "1", "both": "image"},
+		StopSignal: "SIGQUIT",
+		Shell:      []string{"/bin/bash", "-c"},
 	}
 
 	volumesUser := make(map[string]struct{})
@@ -208,6 +235,7 @@ func TestMerge(t *testing.T) {
 		PortSpecs: []string{"3333:2222", "3333:3333"},
 		Env:       []string{"VAR2=3", "VAR3=3"},
 		Volumes:   volumesUser,
+		Labels:    map[string]string{"user": "2", "both": "user"},
 	}
 
 	if err := Merge(configUser, configImage); err != nil {
@@ -240,6 +268,13 @@ func TestMerge(t *testing.T) {
 		}
 	}
 
+	if len(configUser.Labels) != 3 || configUser.Labels["image"] != "1" || configUser.Labels["user"] != "2" || configUser.Labels["both"] != "user" {
+		t.Fatalf("Expected labels image=1, user=2 and both=user, found %v", configUser.Labels)
+	}
+	if configUser.StopSignal != "SIGQUIT" || len(configUser.Shell) != 2 {
+		t.Fatalf("Expected the StopSignal and Shell of the image, found %q and %v", configUser.StopSignal, configUser.Shell)
+	}
+
 	ports, _, err := nat.ParsePortSpecs([]string{"0000"})
 	if err != nil {
 		t.Error(err)
@@ -2,9 +2,11 @@ package runconfig
 
 import (
 	"strings"
+	"time"
 
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/utils"
 )
 
@@ -66,15 +68,84 @@ func (n IpcMode) Container() string {
 	return ""
 }
 
+type PidMode string
+
+// IsPrivate indicates whether container use it's private pid stack
+func (n PidMode) IsPrivate() bool {
+	return !(n.IsHost() || n.IsContainer())
+}
+
+func (n PidMode) IsHost() bool {
+	return n == "host"
+}
+
+func (n PidMode) IsContainer() bool {
+	parts := strings.SplitN(string(n), ":", 2)
+	return len(parts) > 1 && parts[0] == "container"
+}
+
+func (n PidMode) Valid() bool {
+	parts := strings.Split(string(n), ":")
+	switch mode := parts[0]; mode {
+	case "", "host":
+	case "container":
+		if len(parts) != 2 || parts[1] == "" {
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (n PidMode) Container() string {
+	parts := strings.SplitN(string(n), ":", 2)
+	if len(parts) > 1 {
+		return parts[1]
+	}
+	return ""
+}
+
+type UTSMode string
+
+// IsPrivate indicates whether container use it's private UTS namespace
+func (n UTSMode) IsPrivate() bool {
+	return !n.IsHost()
+}
+
+func (n UTSMode) IsHost() bool {
+	return n == "host"
+}
+
+func (n UTSMode) Valid() bool {
+	switch n {
+	case "", "host":
+		return true
+	}
+	return false
+}
+
 type DeviceMapping struct {
 	PathOnHost        string
 	PathInContainer   string
 	CgroupPermissions string
 }
 
+// ThrottleDevice limits the rate of the block IO of the container on a
+// device of the host, in bytes or in operations per second.
+type ThrottleDevice struct {
+	Path string
+	Rate uint64
+}
+
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
+	OnUnhealthy       bool          // Kill the container when it becomes unhealthy so that the policy applies
+	InitialDelay      time.Duration // Delay before the first restart, doubled on each quick exit (0 means the default)
+	MaximumDelay      time.Duration // Upper bound of the delay between two restarts (0 means the default)
+	ResetWindow       time.Duration // Run time after which the delay is reset to InitialDelay (0 means the default)
+	Jitter            float64       // Fraction of the delay, between 0 and 1, randomly added or removed
 }
 
 type HostConfig struct {
@@ -92,10 +163,26 @@ type HostConfig struct {
 	Devices         []DeviceMapping
 	NetworkMode     NetworkMode
 	IpcMode         IpcMode
+	PidMode         PidMode
+	UTSMode         UTSMode
 	CapAdd          []string
 	CapDrop         []string
 	RestartPolicy   RestartPolicy
 	SecurityOpt     []string
+	ReadonlyRootfs  bool
+	Ulimits         []*ulimit.Ulimit
+	CpuPeriod       int64 // CPU CFS period (in microseconds)
+	CpuQuota        int64 // CPU CFS quota (in microseconds)
+	BlkioWeight     int64 // Block IO weight (relative weight vs. other containers)
+	OomKillDisable  bool
+	CgroupParent    string            // Parent cgroup of the container, the daemon default if empty
+	Tmpfs           map[string]string // Options of the tmpfs mounts by destination
+	Sysctls         map[string]string // Namespaced kernel parameters set in the container
+
+	BlkioDeviceReadBps   []*ThrottleDevice
+	BlkioDeviceWriteBps  []*ThrottleDevice
+	BlkioDeviceReadIOps  []*ThrottleDevice
+	BlkioDeviceWriteIOps []*ThrottleDevice
 }
 
 // This is used by the create command when you want to set both the
@@ -125,12 +212,27 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 		PublishAllPorts: job.GetenvBool("PublishAllPorts"),
 		NetworkMode:     NetworkMode(job.Getenv("NetworkMode")),
 		IpcMode:         IpcMode(job.Getenv("IpcMode")),
+		PidMode:         PidMode(job.Getenv("PidMode")),
+		UTSMode:         UTSMode(job.Getenv("UTSMode")),
+		ReadonlyRootfs:  job.GetenvBool("ReadonlyRootfs"),
+		CpuPeriod:       job.GetenvInt64("CpuPeriod"),
+		CpuQuota:        job.GetenvInt64("CpuQuota"),
+		BlkioWeight:     job.GetenvInt64("BlkioWeight"),
+		OomKillDisable:  job.GetenvBool("OomKillDisable"),
+		CgroupParent:    job.Getenv("CgroupParent"),
 	}
 
 	job.GetenvJson("LxcConf", &hostConfig.LxcConf)
 	job.GetenvJson("PortBindings", &hostConfig.PortBindings)
 	job.GetenvJson("Devices", &hostConfig.Devices)
 	job.GetenvJson("RestartPolicy", &hostConfig.RestartPolicy)
+	job.GetenvJson("Ulimits", &hostConfig.Ulimits)
+	job.GetenvJson("Tmpfs", &hostConfig.Tmpfs)
+	job.GetenvJson("Sysctls", &hostConfig.Sysctls)
+	job.GetenvJson("BlkioDeviceReadBps", &hostConfig.BlkioDeviceReadBps)
+	job.GetenvJson("BlkioDeviceWriteBps", &hostConfig.BlkioDeviceWriteBps)
+	job.GetenvJson("BlkioDeviceReadIOps", &hostConfig.BlkioDeviceReadIOps)
+	job.GetenvJson("BlkioDeviceWriteIOps", &hostConfig.BlkioDeviceWriteIOps)
 	hostConfig.SecurityOpt = job.GetenvList("SecurityOpt")
 	if Binds := job.GetenvList("Binds"); Binds != nil {
 		hostConfig.Binds = Binds
@@ -2,14 +2,18 @@ package runconfig
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/mount"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/utils"
 )
@@ -21,6 +25,7 @@ var (
 	ErrConflictNetworkHostname          = fmt.Errorf("Conflicting options: -h and the network mode (--net)")
 	ErrConflictHostNetworkAndDns        = fmt.Errorf("Conflicting options: --net=host can't be used with --dns. This configuration is invalid.")
 	ErrConflictHostNetworkAndLinks      = fmt.Errorf("Conflicting options: --net=host can't be used with links. This would result in undefined behavior.")
+	ErrConflictUTSHostname              = fmt.Errorf("Conflicting options: -h and --uts=host")
 )
 
 func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSet, error) {
@@ -40,12 +45,25 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flVolumesFrom = opts.NewListOpts(nil)
 		flLxcOpts     = opts.NewListOpts(nil)
 		flEnvFile     = opts.NewListOpts(nil)
+		flLabels      = opts.NewListOpts(nil)
+		flLabelsFile  = opts.NewListOpts(nil)
 		flCapAdd      = opts.NewListOpts(nil)
 		flCapDrop     = opts.NewListOpts(nil)
 		flSecurityOpt = opts.NewListOpts(nil)
+		flTmpfs       = opts.NewListOpts(nil)
+		flSysctls     = opts.NewListOpts(nil)
+
+		flDeviceReadBps   = opts.NewListOpts(nil)
+		flDeviceWriteBps  = opts.NewListOpts(nil)
+		flDeviceReadIOps  = opts.NewListOpts(nil)
+		flDeviceWriteIOps = opts.NewListOpts(nil)
+
+		ulimits   = make(map[string]*ulimit.Ulimit)
+		flUlimits = opts.NewUlimitOpt(ulimits)
 
 		flNetwork         = cmd.Bool([]string{"#n", "#-networking"}, true, "Enable networking for this container")
 		flPrivileged      = cmd.Bool([]string{"#privileged", "-privileged"}, false, "Give extended privileges to this container")
+		flReadonlyRootfs  = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
 		flPublishAll      = cmd.Bool([]string{"P", "-publish-all"}, false, "Publish all exposed ports to the host interfaces")
 		flStdin           = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
 		flTty             = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
@@ -53,23 +71,45 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flEntrypoint      = cmd.String([]string{"#entrypoint", "-entrypoint"}, "", "Overwrite the default ENTRYPOINT of the image")
 		flHostname        = cmd.String([]string{"h", "-hostname"}, "", "Container host name")
 		flMemoryString    = cmd.String([]string{"m", "-memory"}, "", "Memory limit (format: <number><optional unit>, where unit = b, k, m or g)")
+		flMemorySwap      = cmd.String([]string{"-memory-swap"}, "", "Total memory usage (memory + swap), '-1' for unlimited swap (format: <number><optional unit>, where unit = b, k, m or g)")
+		flOomKillDisable  = cmd.Bool([]string{"-oom-kill-disable"}, false, "Disable the OOM killer for the container")
 		flUser            = cmd.String([]string{"u", "-user"}, "", "Username or UID")
 		flWorkingDir      = cmd.String([]string{"w", "-workdir"}, "", "Working directory inside the container")
 		flCpuShares       = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
 		flCpuset          = cmd.String([]string{"-cpuset"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+		flCpuPeriod       = cmd.Int64([]string{"-cpu-period"}, 0, "Limit the CPU CFS (Completely Fair Scheduler) period, in microseconds")
+		flCpuQuota        = cmd.Int64([]string{"-cpu-quota"}, 0, "Limit the CPU CFS (Completely Fair Scheduler) quota, in microseconds per period")
+		flBlkioWeight     = cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO weight (relative weight), between 10 and 1000")
 		flNetMode         = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container\n'bridge': creates a new network stack for the container on the docker bridge\n'none': no networking for this container\n'container:<name|id>': reuses another container network stack\n'host': use the host network stack inside the container.  Note: the host mode gives the container full access to local system services such as D-bus and is therefore considered insecure.")
 		flMacAddress      = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
 		flIpcMode         = cmd.String([]string{"-ipc"}, "", "Default is to create a private IPC namespace (POSIX SysV IPC) for the container\n'container:<name|id>': reuses another container shared memory, semaphores and message queues\n'host': use the host shared memory,semaphores and message queues inside the container.  Note: the host mode gives the container full access to local shared memory and is therefore considered insecure.")
-		flRestartPolicy   = cmd.String([]string{"-restart"}, "", "Restart policy to apply when a container exits (no, on-failure[:max-retry], always)")
+		flPidMode         = cmd.String([]string{"-pid"}, "", "Default is to create a private PID namespace for the container\n'container:<name|id>': joins another container's PID namespace\n'host': use the host PID namespace inside the container.  Note: the host mode gives the container full access to the processes of the system and is therefore considered insecure.")
+		flCgroupParent    = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+		flUTSMode         = cmd.String([]string{"-uts"}, "", "Default is to create a private UTS namespace for the container\n'host': use the host UTS namespace inside the container.  Note: the host mode gives the container full access to changing the hostname of the host and is therefore considered insecure.")
+		flRestartPolicy   = cmd.String([]string{"-restart"}, "", "Restart policy to apply when a container exits (no, on-failure[:max-retry], always, unless-stopped) with optional backoff settings (initial-delay, max-delay, reset-window, jitter)")
+		flOnUnhealthy     = cmd.Bool([]string{"-restart-unhealthy"}, false, "Kill the container when it becomes unhealthy so that its restart policy applies")
+		flHealthCmd       = cmd.String([]string{"-health-cmd"}, "", "Command to run inside the container to check its health")
+		flHealthTCP       = cmd.String([]string{"-health-tcp"}, "", "Port of the container to connect to in order to check its health")
+		flHealthHTTP      = cmd.String([]string{"-health-http"}, "", "Port and path of the container to GET in order to check its health (e.g. 8080/ping)")
+		flHealthInterval  = cmd.Duration([]string{"-health-interval"}, 0, "Time between running the health check (default 30s)")
+		flHealthTimeout   = cmd.Duration([]string{"-health-timeout"}, 0, "Maximum time to allow one health check to run (default 30s)")
+		flHealthRetries   = cmd.Int([]string{"-health-retries"}, 0, "Consecutive failures needed to report the container as unhealthy (default 3)")
+		flNoHealthcheck   = cmd.Bool([]string{"-no-healthcheck"}, false, "Disable any health check specified by the image")
 	)
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR.")
 	cmd.Var(&flVolumes, []string{"v", "-volume"}, "Bind mount a volume (e.g., from the host: -v /host:/container, from Docker: -v /container)")
 	cmd.Var(&flLinks, []string{"#link", "-link"}, "Add link to another container in the form of name:alias")
 	cmd.Var(&flDevices, []string{"-device"}, "Add a host device to the container (e.g. --device=/dev/sdc:/dev/xvdc:rwm)")
+	cmd.Var(&flDeviceReadBps, []string{"-device-read-bps"}, "Limit the read rate from a device, in bytes per second (e.g. --device-read-bps=/dev/sda:1mb)")
+	cmd.Var(&flDeviceWriteBps, []string{"-device-write-bps"}, "Limit the write rate to a device, in bytes per second (e.g. --device-write-bps=/dev/sda:1mb)")
+	cmd.Var(&flDeviceReadIOps, []string{"-device-read-iops"}, "Limit the read rate from a device, in IO operations per second (e.g. --device-read-iops=/dev/sda:1000)")
+	cmd.Var(&flDeviceWriteIOps, []string{"-device-write-iops"}, "Limit the write rate to a device, in IO operations per second (e.g. --device-write-iops=/dev/sda:1000)")
 
 	cmd.Var(&flEnv, []string{"e", "-env"}, "Set environment variables")
 	cmd.Var(&flEnvFile, []string{"-env-file"}, "Read in a line delimited file of environment variables")
+	cmd.Var(&flLabels, []string{"l", "-label"}, "Set metadata on the container (e.g., --label=com.example.key=value)")
+	cmd.Var(&flLabelsFile, []string{"-label-file"}, "Read in a line delimited file of labels")
 
 	cmd.Var(&flPublish, []string{"p", "-publish"}, fmt.Sprintf("Publish a container's port to the host\nformat: %s\n(use 'docker port' to see the actual mapping)", nat.PortSpecTemplateFormat))
 	cmd.Var(&flExpose, []string{"#expose", "-expose"}, "Expose a port or a range of ports (e.g. --expose=3300-3310) from the container without publishing it to your host")
@@ -77,11 +117,14 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flDnsSearch, []string{"-dns-search"}, "Set custom DNS search domains (Use --dns-search=. if you don't wish to set the search domain)")
 	cmd.Var(&flExtraHosts, []string{"-add-host"}, "Add a custom host-to-IP mapping (host:ip)")
 	cmd.Var(&flVolumesFrom, []string{"#volumes-from", "-volumes-from"}, "Mount volumes from the specified container(s)")
+	cmd.Var(&flSysctls, []string{"-sysctl"}, "Set a namespaced kernel parameter (e.g., --sysctl net.core.somaxconn=1024)")
+	cmd.Var(&flTmpfs, []string{"-tmpfs"}, "Mount a tmpfs directory (e.g., --tmpfs /run:size=64m,mode=1777)")
 	cmd.Var(&flLxcOpts, []string{"#lxc-conf", "-lxc-conf"}, "(lxc exec-driver only) Add custom lxc options --lxc-conf=\"lxc.cgroup.cpuset.cpus = 0,1\"")
 
 	cmd.Var(&flCapAdd, []string{"-cap-add"}, "Add Linux capabilities")
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
+	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options (e.g., --ulimit nofile=1024:2048)")
 
 	if err := cmd.Parse(args); err != nil {
 		return nil, nil, cmd, err
@@ -136,6 +179,17 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flMemory = parsedMemory
 	}
 
+	var memorySwap int64
+	if *flMemorySwap == "-1" {
+		memorySwap = -1
+	} else if *flMemorySwap != "" {
+		parsedMemorySwap, err := units.RAMInBytes(*flMemorySwap)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		memorySwap = parsedMemorySwap
+	}
+
 	var binds []string
 	// add any bind targets to the list of container volumes
 	for bind := range flVolumes.GetMap() {
@@ -152,6 +206,11 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	tmpfs, err := parseTmpfs(flTmpfs.GetAll(), flVolumes.GetMap(), binds)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	var (
 		parsedArgs = cmd.Args()
 		runCmd     []string
@@ -224,6 +283,24 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		deviceMappings = append(deviceMappings, deviceMapping)
 	}
 
+	// parse the block IO throttles
+	readBps, err := parseThrottleDevices(flDeviceReadBps.GetAll(), parseBpsRate)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	writeBps, err := parseThrottleDevices(flDeviceWriteBps.GetAll(), parseBpsRate)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	readIOps, err := parseThrottleDevices(flDeviceReadIOps.GetAll(), parseIOpsRate)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	writeIOps, err := parseThrottleDevices(flDeviceWriteIOps.GetAll(), parseIOpsRate)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	// collect all the environment variables for the container
 	envVariables := []string{}
 	for _, ef := range flEnvFile.GetAll() {
@@ -236,20 +313,75 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	// parse the '-e' and '--env' after, to allow override
 	envVariables = append(envVariables, flEnv.GetAll()...)
 
+	// collect all the labels for the container, '-l' and '--label' override
+	// the label files
+	labels := map[string]string{}
+	for _, lf := range flLabelsFile.GetAll() {
+		parsedLabels, err := opts.ParseLabelFile(lf)
+		if err != nil {
+			return nil, nil, cmd, err
+		}
+		addLabels(labels, parsedLabels)
+	}
+	addLabels(labels, flLabels.GetAll())
+
 	ipcMode := IpcMode(*flIpcMode)
 	if !ipcMode.Valid() {
 		return nil, nil, cmd, fmt.Errorf("--ipc: invalid IPC mode: %v", err)
 	}
 
+	pidMode := PidMode(*flPidMode)
+	if !pidMode.Valid() {
+		return nil, nil, cmd, fmt.Errorf("--pid: invalid PID mode: %s", *flPidMode)
+	}
+
+	utsMode := UTSMode(*flUTSMode)
+	if !utsMode.Valid() {
+		return nil, nil, cmd, fmt.Errorf("--uts: invalid UTS mode: %s", *flUTSMode)
+	}
+	if utsMode.IsHost() && *flHostname != "" {
+		return nil, nil, cmd, ErrConflictUTSHostname
+	}
+
+	securityOpts, err := parseSecurityOpts(flSecurityOpt.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	netMode, err := parseNetMode(*flNetMode)
 	if err != nil {
 		return nil, nil, cmd, fmt.Errorf("--net: invalid net mode: %v", err)
 	}
 
+	sysctls, err := parseSysctls(flSysctls.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	if err := ValidateSysctls(sysctls, netMode, ipcMode); err != nil {
+		return nil, nil, cmd, err
+	}
+
 	restartPolicy, err := parseRestartPolicy(*flRestartPolicy)
 	if err != nil {
 		return nil, nil, cmd, err
 	}
+	restartPolicy.OnUnhealthy = *flOnUnhealthy
+
+	healthcheck, err := parseHealthcheck(*flHealthCmd, *flHealthTCP, *flHealthHTTP, *flNoHealthcheck)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	if *flHealthInterval < 0 || *flHealthTimeout < 0 || *flHealthRetries < 0 {
+		return nil, nil, cmd, fmt.Errorf("--health-interval, --health-timeout and --health-retries cannot be negative")
+	}
+	if *flHealthInterval != 0 || *flHealthTimeout != 0 || *flHealthRetries != 0 {
+		if healthcheck == nil {
+			healthcheck = &HealthConfig{}
+		}
+		healthcheck.Interval = *flHealthInterval
+		healthcheck.Timeout = *flHealthTimeout
+		healthcheck.Retries = *flHealthRetries
+	}
 
 	config := &Config{
 		Hostname:        hostname,
@@ -261,6 +393,7 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		NetworkDisabled: !*flNetwork,
 		OpenStdin:       *flStdin,
 		Memory:          flMemory,
+		MemorySwap:      memorySwap,
 		CpuShares:       *flCpuShares,
 		Cpuset:          *flCpuset,
 		AttachStdin:     attachStdin,
@@ -273,6 +406,8 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		MacAddress:      *flMacAddress,
 		Entrypoint:      entrypoint,
 		WorkingDir:      *flWorkingDir,
+		Healthcheck:     healthcheck,
+		Labels:          labels,
 	}
 
 	hostConfig := &HostConfig{
@@ -289,11 +424,31 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		VolumesFrom:     flVolumesFrom.GetAll(),
 		NetworkMode:     netMode,
 		IpcMode:         ipcMode,
+		PidMode:         pidMode,
+		UTSMode:         utsMode,
 		Devices:         deviceMappings,
 		CapAdd:          flCapAdd.GetAll(),
 		CapDrop:         flCapDrop.GetAll(),
 		RestartPolicy:   restartPolicy,
-		SecurityOpt:     flSecurityOpt.GetAll(),
+		SecurityOpt:     securityOpts,
+		ReadonlyRootfs:  *flReadonlyRootfs,
+		Ulimits:         flUlimits.GetList(),
+		CgroupParent:    *flCgroupParent,
+		Tmpfs:           tmpfs,
+		Sysctls:         sysctls,
+		CpuPeriod:       *flCpuPeriod,
+		CpuQuota:        *flCpuQuota,
+		BlkioWeight:     *flBlkioWeight,
+		OomKillDisable:  *flOomKillDisable,
+
+		BlkioDeviceReadBps:   readBps,
+		BlkioDeviceWriteBps:  writeBps,
+		BlkioDeviceReadIOps:  readIOps,
+		BlkioDeviceWriteIOps: writeIOps,
+	}
+
+	if err := ValidateResources(config, hostConfig); err != nil {
+		return nil, nil, cmd, err
 	}
 
 	// When allocating stdin in attached mode, close stdin at client disconnect
@@ -303,6 +458,19 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	return config, hostConfig, cmd, nil
 }
 
+// addLabels sets the key=value labels in labels. A label given without a
+// value is set to the empty string.
+func addLabels(labels map[string]string, values []string) {
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) == 1 {
+			labels[parts[0]] = ""
+		} else {
+			labels[parts[0]] = parts[1]
+		}
+	}
+}
+
 // parseRestartPolicy returns the parsed policy or an error indicating what is incorrect
 func parseRestartPolicy(policy string) (RestartPolicy, error) {
 	p := RestartPolicy{}
@@ -312,19 +480,22 @@ func parseRestartPolicy(policy string) (RestartPolicy, error) {
 	}
 
 	var (
-		parts = strings.Split(policy, ":")
-		name  = parts[0]
+		options = strings.Split(policy, ",")
+		parts   = strings.Split(options[0], ":")
+		name    = parts[0]
 	)
 
 	switch name {
-	case "always":
+	case "always", "unless-stopped":
 		p.Name = name
 
 		if len(parts) == 2 {
-			return p, fmt.Errorf("maximum restart count not valid with restart policy of \"always\"")
+			return p, fmt.Errorf("maximum restart count not valid with restart policy of \"%s\"", name)
 		}
 	case "no":
-		// do nothing
+		if len(options) > 1 {
+			return p, fmt.Errorf("restart options not valid with restart policy of \"no\"")
+		}
 	case "on-failure":
 		p.Name = name
 
@@ -340,9 +511,91 @@ func parseRestartPolicy(policy string) (RestartPolicy, error) {
 		return p, fmt.Errorf("invalid restart policy %s", name)
 	}
 
+	for _, option := range options[1:] {
+		key, value, err := parsers.ParseKeyValueOpt(option)
+		if err != nil {
+			return p, fmt.Errorf("invalid restart option %s", option)
+		}
+
+		switch key {
+		case "initial-delay", "max-delay", "reset-window":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return p, fmt.Errorf("invalid restart option %s: %v", option, err)
+			}
+			if d < 0 {
+				return p, fmt.Errorf("invalid restart option %s: must not be negative", option)
+			}
+
+			switch key {
+			case "initial-delay":
+				p.InitialDelay = d
+			case "max-delay":
+				p.MaximumDelay = d
+			case "reset-window":
+				p.ResetWindow = d
+			}
+		case "jitter":
+			jitter, err := strconv.ParseFloat(value, 64)
+			if err != nil || jitter < 0 || jitter > 1 {
+				return p, fmt.Errorf("invalid restart option %s: must be between 0 and 1", option)
+			}
+
+			p.Jitter = jitter
+		default:
+			return p, fmt.Errorf("invalid restart option %s", key)
+		}
+	}
+
+	if p.MaximumDelay != 0 && p.InitialDelay > p.MaximumDelay {
+		return p, fmt.Errorf("restart initial-delay (%s) must not exceed max-delay (%s)", p.InitialDelay, p.MaximumDelay)
+	}
+
 	return p, nil
 }
 
+// parseHealthcheck returns the health check described by the --health-cmd,
+// --health-tcp, --health-http and --no-healthcheck flags, or nil if none
+// of them is set.
+func parseHealthcheck(command, tcpPort, httpTarget string, disable bool) (*HealthConfig, error) {
+	var test []string
+	n := 0
+	if command != "" {
+		test = []string{"CMD-SHELL", command}
+		n++
+	}
+	if tcpPort != "" {
+		if _, err := strconv.ParseUint(tcpPort, 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid --health-tcp port: %s", tcpPort)
+		}
+		test = []string{"TCP", tcpPort}
+		n++
+	}
+	if httpTarget != "" {
+		parts := strings.SplitN(httpTarget, "/", 2)
+		if _, err := strconv.ParseUint(parts[0], 10, 16); err != nil {
+			return nil, fmt.Errorf("invalid --health-http port: %s", parts[0])
+		}
+		path := "/"
+		if len(parts) == 2 {
+			path += parts[1]
+		}
+		test = []string{"HTTP", parts[0], path}
+		n++
+	}
+	if disable {
+		test = []string{"NONE"}
+		n++
+	}
+	if n > 1 {
+		return nil, fmt.Errorf("Conflicting options: only one of --health-cmd, --health-tcp, --health-http and --no-healthcheck can be used")
+	}
+	if test == nil {
+		return nil, nil
+	}
+	return &HealthConfig{Test: test}, nil
+}
+
 // options will come in the format of name.key=value or name.option
 func parseDriverOpts(opts opts.ListOpts) (map[string][]string, error) {
 	out := make(map[string][]string, len(opts.GetAll()))
@@ -417,3 +670,113 @@ func ParseDevice(device string) (DeviceMapping, error) {
 	}
 	return deviceMapping, nil
 }
+
+// parseThrottleDevices parses block IO throttles given as <device>:<rate>,
+// the rate being parsed by parseRate.
+func parseThrottleDevices(values []string, parseRate func(string) (uint64, error)) ([]*ThrottleDevice, error) {
+	var throttles []*ThrottleDevice
+	for _, value := range values {
+		arr := strings.Split(value, ":")
+		if len(arr) != 2 || !path.IsAbs(arr[0]) {
+			return nil, fmt.Errorf("Invalid throttle specification: %s (expected <device-path>:<rate>)", value)
+		}
+		rate, err := parseRate(arr[1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid throttle rate in %s: %s", value, err)
+		}
+		throttles = append(throttles, &ThrottleDevice{Path: arr[0], Rate: rate})
+	}
+	return throttles, nil
+}
+
+func parseBpsRate(rate string) (uint64, error) {
+	bytes, err := units.RAMInBytes(rate)
+	if err != nil {
+		return 0, err
+	}
+	if bytes <= 0 {
+		return 0, fmt.Errorf("the rate must be a positive number of bytes")
+	}
+	return uint64(bytes), nil
+}
+
+func parseIOpsRate(rate string) (uint64, error) {
+	iops, err := strconv.ParseUint(rate, 10, 64)
+	if err != nil || iops == 0 {
+		return 0, fmt.Errorf("the rate must be a positive number of operations")
+	}
+	return iops, nil
+}
+
+// parseSecurityOpts replaces the path of a seccomp profile by its content, as
+// the daemon may not be able to read the file
+func parseSecurityOpts(securityOpts []string) ([]string, error) {
+	for i, opt := range securityOpts {
+		sep := strings.IndexAny(opt, ":=")
+		if sep == -1 || opt[:sep] != "seccomp" || opt[sep+1:] == "unconfined" {
+			continue
+		}
+		profile, err := ioutil.ReadFile(opt[sep+1:])
+		if err != nil {
+			return nil, fmt.Errorf("Opening seccomp profile (%s) failed: %v", opt[sep+1:], err)
+		}
+		securityOpts[i] = "seccomp=" + string(profile)
+	}
+	return securityOpts, nil
+}
+
+// parseTmpfs returns the options of the tmpfs mounts by destination from
+// the path[:options] values of --tmpfs.  A tmpfs can't be mounted on the
+// destination of a volume.
+func parseTmpfs(specs []string, volumes map[string]struct{}, binds []string) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	destinations := make(map[string]bool)
+	for volume := range volumes {
+		destinations[path.Clean(volume)] = true
+	}
+	for _, bind := range binds {
+		destinations[path.Clean(strings.Split(bind, ":")[1])] = true
+	}
+
+	tmpfs := make(map[string]string)
+	for _, spec := range specs {
+		var (
+			parts       = strings.SplitN(spec, ":", 2)
+			destination = path.Clean(parts[0])
+			options     string
+		)
+		if len(parts) == 2 {
+			options = parts[1]
+		}
+		if !path.IsAbs(destination) || destination == "/" {
+			return nil, fmt.Errorf("Invalid tmpfs %q: the destination must be an absolute path other than '/'", spec)
+		}
+		if _, _, err := mount.ParseTmpfsOptions(options); err != nil {
+			return nil, err
+		}
+		if _, exists := tmpfs[destination]; exists || destinations[destination] {
+			return nil, fmt.Errorf("Duplicate mount point %q for --tmpfs", destination)
+		}
+		tmpfs[destination] = options
+	}
+	return tmpfs, nil
+}
+
+// parseSysctls returns the values by key of the key=value kernel parameters
+// of --sysctl
+func parseSysctls(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	sysctls := make(map[string]string)
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid sysctl %q, expected key=value", value)
+		}
+		sysctls[parts[0]] = parts[1]
+	}
+	return sysctls, nil
+}
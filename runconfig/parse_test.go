@@ -2,7 +2,10 @@ package runconfig
 
 import (
 	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
@@ -57,3 +60,317 @@ func TestNetHostname(t *testing.T) {
 		t.Fatalf("Expected error ErrConflictNetworkHostname, got: %s", err)
 	}
 }
+
+func TestParseHealthcheck(t *testing.T) {
+	config, hostConfig, _, err := parseRun([]string{"--health-http=8080/ping", "--health-interval=5s", "--restart=always", "--restart-unhealthy", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.Healthcheck == nil {
+		t.Fatal("Expected a health check")
+	}
+	if test := config.Healthcheck.Test; len(test) != 3 || test[0] != "HTTP" || test[1] != "8080" || test[2] != "/ping" {
+		t.Fatalf("Unexpected health check test: %v", test)
+	}
+	if config.Healthcheck.Interval != 5*time.Second {
+		t.Fatalf("Expected a 5s interval, got %s", config.Healthcheck.Interval)
+	}
+	if !hostConfig.RestartPolicy.OnUnhealthy {
+		t.Fatal("Expected the restart policy to apply to unhealthy containers")
+	}
+
+	config, _, _, err = parseRun([]string{"--no-healthcheck", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if test := config.Healthcheck.Test; len(test) != 1 || test[0] != "NONE" {
+		t.Fatalf("Expected the health check to be disabled, got %v", test)
+	}
+
+	if _, _, _, err := parseRun([]string{"--health-cmd=true", "--health-tcp=80", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error with conflicting health checks")
+	}
+	if _, _, _, err := parseRun([]string{"--health-tcp=http", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error with an invalid port")
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--restart=on-failure:3,initial-delay=1s,max-delay=30s,reset-window=1m,jitter=0.2", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := RestartPolicy{
+		Name:              "on-failure",
+		MaximumRetryCount: 3,
+		InitialDelay:      time.Second,
+		MaximumDelay:      30 * time.Second,
+		ResetWindow:       time.Minute,
+		Jitter:            0.2,
+	}
+	if hostConfig.RestartPolicy != expected {
+		t.Fatalf("Expected restart policy %+v, got %+v", expected, hostConfig.RestartPolicy)
+	}
+
+	_, hostConfig, _, err = parseRun([]string{"--restart=unless-stopped", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.RestartPolicy.Name != "unless-stopped" {
+		t.Fatalf("Expected the unless-stopped restart policy, got %s", hostConfig.RestartPolicy.Name)
+	}
+
+	for _, policy := range []string{
+		"unless-stopped:3",
+		"no,max-delay=1s",
+		"always,max-delay=-1s",
+		"always,jitter=2",
+		"always,delay=1s",
+		"always,initial-delay=1m,max-delay=1s",
+	} {
+		if _, _, _, err := parseRun([]string{"--restart=" + policy, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error with restart policy %s", policy)
+		}
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	f, err := ioutil.TempFile("", "labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("# comment\nfile=a\nover=file\nempty\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	config, _, _, err := parseRun([]string{"--label-file", f.Name(), "-l", "over=flag", "--label=with=equals", "--label", "bare", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"file":  "a",
+		"over":  "flag",
+		"empty": "",
+		"with":  "equals",
+		"bare":  "",
+	}
+	if len(config.Labels) != len(expected) {
+		t.Fatalf("Expected labels %v, got %v", expected, config.Labels)
+	}
+	for k, v := range expected {
+		if value, exists := config.Labels[k]; !exists || value != v {
+			t.Fatalf("Expected label %s=%s, got %v", k, v, config.Labels)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--label-file", "/nonexistent", "img"}); err == nil {
+		t.Fatal("Expected an error for a missing label file")
+	}
+}
+
+func TestParsePidAndUTSModes(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--pid=host", "--uts=host", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hostConfig.PidMode.IsHost() || !hostConfig.UTSMode.IsHost() {
+		t.Fatalf("Expected host PID and UTS modes, got %q and %q", hostConfig.PidMode, hostConfig.UTSMode)
+	}
+
+	_, hostConfig, _, err = parseRun([]string{"--pid=container:other", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hostConfig.PidMode.IsContainer() || hostConfig.PidMode.Container() != "other" {
+		t.Fatalf("Expected to join the PID namespace of other, got %q", hostConfig.PidMode)
+	}
+
+	for _, mode := range []string{"--pid=container", "--pid=container:", "--pid=foo", "--uts=container:other", "--uts=foo"} {
+		if _, _, _, err := parseRun([]string{mode, "img"}); err == nil {
+			t.Fatalf("Expected an error for %s", mode)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"-h=name", "--uts=host", "img"}); err != ErrConflictUTSHostname {
+		t.Fatalf("Expected error ErrConflictUTSHostname, got: %s", err)
+	}
+}
+
+func TestParseSeccompProfile(t *testing.T) {
+	f, err := ioutil.TempFile("", "seccomp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	profile := `{"defaultAction": "SCMP_ACT_ALLOW"}`
+	if _, err := f.WriteString(profile); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, hostConfig, _, err := parseRun([]string{"--security-opt", "seccomp=" + f.Name(), "--security-opt", "label:disable", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.SecurityOpt) != 2 || hostConfig.SecurityOpt[0] != "seccomp="+profile || hostConfig.SecurityOpt[1] != "label:disable" {
+		t.Fatalf("Expected the content of the seccomp profile, got %v", hostConfig.SecurityOpt)
+	}
+
+	_, hostConfig, _, err = parseRun([]string{"--security-opt", "seccomp=unconfined", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.SecurityOpt) != 1 || hostConfig.SecurityOpt[0] != "seccomp=unconfined" {
+		t.Fatalf("Expected seccomp=unconfined, got %v", hostConfig.SecurityOpt)
+	}
+
+	if _, _, _, err := parseRun([]string{"--security-opt", "seccomp=/does/not/exist", "img"}); err == nil {
+		t.Fatal("Expected an error for a missing seccomp profile")
+	}
+}
+
+func TestParseUlimits(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--ulimit", "nofile=1024:2048", "--ulimit", "nproc=512", "--ulimit", "nofile=42:42", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hostConfig.Ulimits) != 2 {
+		t.Fatalf("Expected 2 ulimits, got %v", hostConfig.Ulimits)
+	}
+	for _, u := range hostConfig.Ulimits {
+		switch u.Name {
+		case "nofile":
+			if u.Soft != 42 || u.Hard != 42 {
+				t.Fatalf("Expected the last nofile value to win, got %s", u)
+			}
+		case "nproc":
+			if u.Soft != 512 || u.Hard != 512 {
+				t.Fatalf("Expected nproc=512:512, got %s", u)
+			}
+		default:
+			t.Fatalf("Unexpected ulimit %s", u)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--ulimit", "nofile=2048:1024", "img"}); err == nil {
+		t.Fatal("Expected an error for a soft limit above the hard limit")
+	}
+	if _, _, _, err := parseRun([]string{"--ulimit", "notalimit=1", "img"}); err == nil {
+		t.Fatal("Expected an error for an unknown ulimit")
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	config, hostConfig, _, err := parseRun([]string{
+		"-m", "32m", "--memory-swap", "64m",
+		"--cpu-period", "50000", "--cpu-quota", "25000",
+		"--blkio-weight", "300", "--oom-kill-disable",
+		"--device-read-bps", "/dev/sda:1mb", "--device-write-iops", "/dev/sda:100",
+		"img",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Memory != 32*1024*1024 || config.MemorySwap != 64*1024*1024 {
+		t.Fatalf("Expected 32m of memory and 64m of memory and swap, got %d and %d", config.Memory, config.MemorySwap)
+	}
+	if hostConfig.CpuPeriod != 50000 || hostConfig.CpuQuota != 25000 {
+		t.Fatalf("Expected a CFS quota of 25000/50000, got %d/%d", hostConfig.CpuQuota, hostConfig.CpuPeriod)
+	}
+	if hostConfig.BlkioWeight != 300 || !hostConfig.OomKillDisable {
+		t.Fatalf("Expected a block IO weight of 300 and the OOM killer disabled, got %d and %v", hostConfig.BlkioWeight, hostConfig.OomKillDisable)
+	}
+	if len(hostConfig.BlkioDeviceReadBps) != 1 || *hostConfig.BlkioDeviceReadBps[0] != (ThrottleDevice{Path: "/dev/sda", Rate: 1024 * 1024}) {
+		t.Fatalf("Expected a read throttle of 1mb on /dev/sda, got %v", hostConfig.BlkioDeviceReadBps)
+	}
+	if len(hostConfig.BlkioDeviceWriteIOps) != 1 || *hostConfig.BlkioDeviceWriteIOps[0] != (ThrottleDevice{Path: "/dev/sda", Rate: 100}) {
+		t.Fatalf("Expected a write throttle of 100 operations on /dev/sda, got %v", hostConfig.BlkioDeviceWriteIOps)
+	}
+
+	config, _, _, err = parseRun([]string{"--memory-swap", "-1", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MemorySwap != -1 {
+		t.Fatalf("Expected --memory-swap -1 to leave the swap unlimited, got %d", config.MemorySwap)
+	}
+
+	for _, args := range [][]string{
+		{"--memory-swap", "64m"},
+		{"-m", "64m", "--memory-swap", "32m"},
+		{"--cpu-period", "100"},
+		{"--cpu-quota", "100"},
+		{"--blkio-weight", "5"},
+		{"--device-read-bps", "/dev/sda"},
+		{"--device-read-bps", "sda:1mb"},
+		{"--device-write-iops", "/dev/sda:1mb"},
+	} {
+		if _, _, _, err := parseRun(append(args, "img")); err == nil {
+			t.Fatalf("Expected an error for %v", args)
+		}
+	}
+}
+
+func TestParseCgroupParent(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--cgroup-parent", "/teams/a", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.CgroupParent != "/teams/a" {
+		t.Fatalf("Expected the cgroup parent /teams/a, got %q", hostConfig.CgroupParent)
+	}
+}
+
+func TestParseTmpfs(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--tmpfs", "/run:size=64m,mode=1777", "--tmpfs", "/tmp/", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"/run": "size=64m,mode=1777", "/tmp": ""}
+	if !reflect.DeepEqual(hostConfig.Tmpfs, expected) {
+		t.Fatalf("Expected the tmpfs mounts %v, got %v", expected, hostConfig.Tmpfs)
+	}
+
+	for _, args := range [][]string{
+		{"--tmpfs", "run", "img"},
+		{"--tmpfs", "/", "img"},
+		{"--tmpfs", "/run:bind", "img"},
+		{"--tmpfs", "/run", "--tmpfs", "/run:size=1m", "img"},
+		{"--tmpfs", "/data", "-v", "/data", "img"},
+		{"--tmpfs", "/data", "-v", "/srv:/data:ro", "img"},
+	} {
+		if _, _, _, err := parseRun(args); err == nil {
+			t.Fatalf("Expected an error parsing %v", args)
+		}
+	}
+}
+
+func TestParseSysctls(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--sysctl", "net.core.somaxconn=1024", "--sysctl", "net.ipv4.ip_local_port_range=1024 65000", "--sysctl", "kernel.shmmax=68719476736", "img"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"net.core.somaxconn":           "1024",
+		"net.ipv4.ip_local_port_range": "1024 65000",
+		"kernel.shmmax":                "68719476736",
+	}
+	if !reflect.DeepEqual(hostConfig.Sysctls, expected) {
+		t.Fatalf("Expected the sysctls %v, got %v", expected, hostConfig.Sysctls)
+	}
+
+	for _, args := range [][]string{
+		{"--sysctl", "net.core.somaxconn", "img"},
+		{"--sysctl", "kernel.hostname=foo", "img"},
+		{"--sysctl", "vm.swappiness=10", "img"},
+		{"--sysctl", "net./../../kernel/core_pattern=|/tmp/x", "img"},
+		{"--net=host", "--sysctl", "net.core.somaxconn=1024", "img"},
+		{"--ipc=host", "--sysctl", "kernel.msgmax=65536", "img"},
+	} {
+		if _, _, _, err := parseRun(args); err == nil {
+			t.Fatalf("Expected an error parsing %v", args)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package runconfig
+
+import (
+	"fmt"
+)
+
+var (
+	ErrConflictMemorySwapWithoutMemory = fmt.Errorf("Conflicting options: --memory-swap can't be used without --memory.")
+	ErrInvalidMemorySwap               = fmt.Errorf("The memory swap limit must be larger than the memory limit.")
+	ErrInvalidCpuPeriod                = fmt.Errorf("The CPU CFS period must be between 1000 and 1000000 microseconds.")
+	ErrInvalidCpuQuota                 = fmt.Errorf("The CPU CFS quota must be at least 1000 microseconds, or -1 for no quota.")
+	ErrInvalidBlkioWeight              = fmt.Errorf("The block IO weight must be between 10 and 1000.")
+)
+
+// ValidateResources checks that the resource limits of a container are
+// consistent and within the ranges accepted by the kernel. The hostConfig
+// may be nil.
+func ValidateResources(config *Config, hostConfig *HostConfig) error {
+	if config.MemorySwap > 0 {
+		if config.Memory == 0 {
+			return ErrConflictMemorySwapWithoutMemory
+		}
+		if config.MemorySwap < config.Memory {
+			return ErrInvalidMemorySwap
+		}
+	}
+
+	if hostConfig == nil {
+		return nil
+	}
+	if hostConfig.CpuPeriod != 0 && (hostConfig.CpuPeriod < 1000 || hostConfig.CpuPeriod > 1000000) {
+		return ErrInvalidCpuPeriod
+	}
+	if hostConfig.CpuQuota < -1 || (hostConfig.CpuQuota > 0 && hostConfig.CpuQuota < 1000) {
+		return ErrInvalidCpuQuota
+	}
+	if hostConfig.BlkioWeight != 0 && (hostConfig.BlkioWeight < 10 || hostConfig.BlkioWeight > 1000) {
+		return ErrInvalidBlkioWeight
+	}
+	return nil
+}
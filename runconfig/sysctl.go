@@ -0,0 +1,55 @@
+package runconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sysctls of the IPC namespace, the other kernel parameters are global to
+// the host except the net.* ones of the network namespace
+var ipcSysctls = map[string]bool{
+	"kernel.msgmax":          true,
+	"kernel.msgmnb":          true,
+	"kernel.msgmni":          true,
+	"kernel.sem":             true,
+	"kernel.shmall":          true,
+	"kernel.shmmax":          true,
+	"kernel.shmmni":          true,
+	"kernel.shm_rmid_forced": true,
+}
+
+// ValidateSysctls checks that the kernel parameters of a container are
+// namespaced, and that the container has its own namespace for them
+func ValidateSysctls(sysctls map[string]string, netMode NetworkMode, ipcMode IpcMode) error {
+	for key := range sysctls {
+		if !validSysctlKey(key) {
+			return fmt.Errorf("Invalid sysctl %q", key)
+		}
+		switch {
+		case ipcSysctls[key], strings.HasPrefix(key, "fs.mqueue."):
+			if ipcMode.IsHost() || ipcMode.IsContainer() {
+				return fmt.Errorf("sysctl %s can't be set with --ipc=%s, it needs a private IPC namespace", key, ipcMode)
+			}
+		case strings.HasPrefix(key, "net."):
+			if netMode.IsHost() || netMode.IsContainer() {
+				return fmt.Errorf("sysctl %s can't be set with --net=%s, it needs a private network namespace", key, netMode)
+			}
+		default:
+			return fmt.Errorf("sysctl %s is not whitelisted, only the namespaced net.*, kernel.msg*, kernel.sem, kernel.shm* and fs.mqueue.* sysctls can be set", key)
+		}
+	}
+	return nil
+}
+
+// validSysctlKey rejects the keys whose file would not be under /proc/sys
+func validSysctlKey(key string) bool {
+	if strings.ContainsAny(key, "/\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, ".") {
+		if part == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -73,16 +73,63 @@ func (p *JSONProgress) String() string {
 	return pbBox + numbersBox + timeLeftBox
 }
 
+// The events of a build step
+const (
+	StepStart     = "start"     // the step begins, with its instruction
+	StepCache     = "cache"     // the step is cached
+	StepContainer = "container" // the intermediate container of the step is created
+	StepOutput    = "output"    // the intermediate container writes some output
+	StepDone      = "done"      // the step is done, with its resulting image
+)
+
+// JSONStep is an event of a build step, sent instead of the text of the step
+// when the client asks for it. The done event sums up the step.
+type JSONStep struct {
+	Index       int           `json:"index"`
+	Event       string        `json:"event"`
+	Instruction string        `json:"instruction,omitempty"`
+	Trigger     bool          `json:"trigger,omitempty"` // the start event is the one of an ONBUILD trigger run by the step
+	Cached      bool          `json:"cached,omitempty"`
+	ContainerID string        `json:"containerID,omitempty"`
+	ImageID     string        `json:"imageID,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+	Output      string        `json:"output,omitempty"`
+	Stderr      bool          `json:"stderr,omitempty"`
+}
+
+// String returns the text of the build output for the event.
+func (s *JSONStep) String() string {
+	switch s.Event {
+	case StepStart:
+		return fmt.Sprintf("Step %d : %s\n", s.Index, s.Instruction)
+	case StepCache:
+		return " ---> Using cache\n"
+	case StepContainer:
+		return fmt.Sprintf(" ---> Running in %s\n", TruncateID(s.ContainerID))
+	case StepOutput:
+		if s.Stderr {
+			return "\033[91m" + s.Output + "\033[0m"
+		}
+		return s.Output
+	case StepDone:
+		return fmt.Sprintf(" ---> %s\n", TruncateID(s.ImageID))
+	}
+	return ""
+}
+
 type JSONMessage struct {
-	Stream          string        `json:"stream,omitempty"`
-	Status          string        `json:"status,omitempty"`
-	Progress        *JSONProgress `json:"progressDetail,omitempty"`
-	ProgressMessage string        `json:"progress,omitempty"` //deprecated
-	ID              string        `json:"id,omitempty"`
-	From            string        `json:"from,omitempty"`
-	Time            int64         `json:"time,omitempty"`
-	Error           *JSONError    `json:"errorDetail,omitempty"`
-	ErrorMessage    string        `json:"error,omitempty"` //deprecated
+	Stream          string            `json:"stream,omitempty"`
+	Status          string            `json:"status,omitempty"`
+	Progress        *JSONProgress     `json:"progressDetail,omitempty"`
+	ProgressMessage string            `json:"progress,omitempty"` //deprecated
+	ID              string            `json:"id,omitempty"`
+	From            string            `json:"from,omitempty"`
+	Time            int64             `json:"time,omitempty"`
+	Type            string            `json:"type,omitempty"`
+	Attributes      map[string]string `json:"attributes,omitempty"`
+	Step            *JSONStep         `json:"step,omitempty"`
+	Error           *JSONError        `json:"errorDetail,omitempty"`
+	ErrorMessage    string            `json:"error,omitempty"` //deprecated
 }
 
 func (jm *JSONMessage) Display(out io.Writer, isTerminal bool) error {
@@ -92,6 +139,10 @@ func (jm *JSONMessage) Display(out io.Writer, isTerminal bool) error {
 		}
 		return jm.Error
 	}
+	if jm.Step != nil {
+		fmt.Fprint(out, jm.Step.String())
+		return nil
+	}
 	var endl string
 	if isTerminal && jm.Stream == "" && jm.Progress != nil {
 		// <ESC>[2K = erase entire current line
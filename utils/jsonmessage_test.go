@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -36,3 +37,25 @@ func TestProgress(t *testing.T) {
 		t.Fatalf("Expected %q, got %q", expected, jp4.String())
 	}
 }
+
+func TestDisplayStep(t *testing.T) {
+	steps := []*JSONStep{
+		{Index: 1, Event: StepStart, Instruction: "RUN echo hi"},
+		{Index: 1, Event: StepContainer, ContainerID: "0123456789abcdef0123"},
+		{Index: 1, Event: StepOutput, Output: "hi\n"},
+		{Index: 1, Event: StepOutput, Output: "oops\n", Stderr: true},
+		{Index: 1, Event: StepDone, ImageID: "fedcba9876543210fedc"},
+		{Index: 2, Event: StepStart, Instruction: "CMD true"},
+		{Index: 2, Event: StepCache},
+	}
+	out := &bytes.Buffer{}
+	for _, step := range steps {
+		if err := (&JSONMessage{Step: step}).Display(out, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "Step 1 : RUN echo hi\n ---> Running in 0123456789ab\nhi\n\033[91moops\n\033[0m ---> fedcba987654\nStep 2 : CMD true\n ---> Using cache\n"
+	if out.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, out.String())
+	}
+}
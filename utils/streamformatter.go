@@ -79,6 +79,17 @@ func (sf *StreamFormatter) FormatProgress(id, action string, progress *JSONProgr
 	return []byte(action + " " + progress.String() + endl)
 }
 
+func (sf *StreamFormatter) FormatStep(step *JSONStep) []byte {
+	if sf.json {
+		b, err := json.Marshal(&JSONMessage{Step: step})
+		if err != nil {
+			return sf.FormatError(err)
+		}
+		return append(b, streamNewlineBytes...)
+	}
+	return []byte(step.String())
+}
+
 func (sf *StreamFormatter) Json() bool {
 	return sf.json
 }
@@ -23,6 +23,14 @@ func TestFormatStatus(t *testing.T) {
 	}
 }
 
+func TestFormatStep(t *testing.T) {
+	sf := NewStreamFormatter(true)
+	res := sf.FormatStep(&JSONStep{Index: 2, Event: StepDone, Cached: true, ImageID: "abc", Duration: 5})
+	if string(res) != `{"step":{"index":2,"event":"done","cached":true,"imageID":"abc","duration":5}}`+"\r\n" {
+		t.Fatalf("%q", res)
+	}
+}
+
 func TestFormatSimpleError(t *testing.T) {
 	sf := NewStreamFormatter(true)
 	res := sf.FormatError(errors.New("Error for formatter"))
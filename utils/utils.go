@@ -444,6 +444,7 @@ func ReadSymlinkedDirectory(path string) (string, error) {
 // can be read and returns an error if some files can't be read
 // symlinks which point to non-existing files don't trigger an error
 func ValidateContextDirectory(srcPath string, excludes []string) error {
+	exceptions := fileutils.HasExceptions(excludes)
 	return filepath.Walk(filepath.Join(srcPath, "."), func(filePath string, f os.FileInfo, err error) error {
 		// skip this directory/file if it's not in the path, it won't get added to the context
 		if relFilePath, err := filepath.Rel(srcPath, filePath); err != nil {
@@ -451,7 +452,7 @@ func ValidateContextDirectory(srcPath string, excludes []string) error {
 		} else if skip, err := fileutils.Matches(relFilePath, excludes); err != nil {
 			return err
 		} else if skip {
-			if f.IsDir() {
+			if f.IsDir() && !exceptions {
 				return filepath.SkipDir
 			}
 			return nil